@@ -0,0 +1,128 @@
+package mpd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ProgressFunc is called as binary data such as cover art is received,
+// with the number of bytes read so far and the total size.
+type ProgressFunc func(read, total int64)
+
+// WithBinaryLimit() raises the size of the chunks the server sends cover
+// art in, as SetBinaryLimit() does, right after connecting. Servers that
+// are too old to support it keep the default of 8 KiB.
+func WithBinaryLimit(size int) Option {
+	return func(opts *options) {
+		opts.binaryLimit = size
+	}
+}
+
+// SetBinaryLimit() sets the largest chunk of binary data the server may
+// send in one response. Raising it from the default of 8 KiB makes large
+// cover art take far fewer round trips. It requires MPD 0.22.4.
+func (conn *Conn) SetBinaryLimit(size int) error {
+	_, err := conn.exec("binarylimit " + strconv.Itoa(size))
+	return err
+}
+
+// negotiateBinaryLimit() sets the binary limit given by WithBinaryLimit()
+// on a new connection if the server supports it. A limit the server
+// rejects is left at its default, since it only affects performance.
+func (s *session) negotiateBinaryLimit() error {
+	if s.opts.binaryLimit <= 0 {
+		return nil
+	}
+	if v, _ := ParseVersion(s.serverVersion()); !v.AtLeast(0, 22, 4) {
+		return nil
+	}
+	_, broken, err := s.roundTrip(nil, time.Time{}, "binarylimit "+strconv.Itoa(s.opts.binaryLimit))
+	if broken {
+		return err
+	}
+	return nil
+}
+
+// AlbumArt() returns the cover art stored in the directory of the given
+// song, such as a cover.png or folder.jpg.
+func (conn *Conn) AlbumArt(uri string) (Picture, error) {
+	var buf bytes.Buffer
+	if _, err := conn.WriteAlbumArt(uri, &buf, nil); err != nil {
+		return Picture{}, err
+	}
+	return NewPicture(buf.Bytes(), ""), nil
+}
+
+// WriteAlbumArt() is like AlbumArt(), but streams the art to w as it is
+// received, calling progress, if not nil, after every chunk. It returns
+// the number of bytes written.
+func (conn *Conn) WriteAlbumArt(uri string, w io.Writer, progress ProgressFunc) (int64, error) {
+	n, _, err := conn.readChunks("albumart", uri, w, progress)
+	return n, err
+}
+
+// ReadPicture() returns the cover art embedded in the tags of the given
+// song, which makes a fallback for songs without an image file next to
+// them. If the song has no embedded art, the returned picture is empty.
+func (conn *Conn) ReadPicture(uri string) (Picture, error) {
+	var buf bytes.Buffer
+	mimeType, _, err := conn.WritePicture(uri, &buf, nil)
+	if err != nil || buf.Len() == 0 {
+		return Picture{}, err
+	}
+	return NewPicture(buf.Bytes(), mimeType), nil
+}
+
+// WritePicture() is like ReadPicture(), but streams the art to w as it is
+// received, calling progress, if not nil, after every chunk. It returns
+// the MIME type reported by the server, if any, and the number of bytes
+// written.
+func (conn *Conn) WritePicture(uri string, w io.Writer, progress ProgressFunc) (string, int64, error) {
+	n, resp, err := conn.readChunks("readpicture", uri, w, progress)
+	return resp.Get("type"), n, err
+}
+
+// readChunks() retrieves binary data for a song with a command taking an
+// offset, such as albumart, sending it repeatedly until all of the data
+// has been written to w. It returns the number of bytes written along
+// with the attributes of the last response.
+func (conn *Conn) readChunks(cmd, uri string, w io.Writer, progress ProgressFunc) (int64, Response, error) {
+	var offset int64
+	for {
+		lines, err := conn.exec(formatCommand(cmd, uri, strconv.FormatInt(offset, 10)))
+		if err != nil {
+			return offset, nil, err
+		}
+		resp := parseResponse(lines)
+		if len(resp) == 0 && offset == 0 {
+			// readpicture responds with nothing at all when there's no
+			// picture.
+			return 0, nil, nil
+		}
+		size, err := strconv.ParseInt(resp.Get("size"), 10, 64)
+		if err != nil {
+			return offset, resp, errors.New("missing size in binary response")
+		}
+		chunk := resp.Get("binary")
+		if chunk != "" {
+			if _, err := io.WriteString(w, chunk); err != nil {
+				return offset, resp, err
+			}
+			if buf, ok := w.(*bytes.Buffer); ok && offset == 0 {
+				// Make room for all of it at once rather than growing
+				// the buffer chunk by chunk.
+				buf.Grow(int(size))
+			}
+			offset += int64(len(chunk))
+		}
+		if progress != nil {
+			progress(offset, size)
+		}
+		if offset >= size || chunk == "" {
+			return offset, resp, nil
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package mpd
+
+import (
+	"bytes"
+	"container/list"
+	"image"
+	"image/color"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"sort"
+	"sync"
+)
+
+// ArtCache caches cover art by song URI, optionally along with
+// downscaled thumbnails, so that clients showing the same art repeatedly
+// don't have to fetch and ship around full-size images every time.
+type ArtCache struct {
+	// Fetch retrieves the art for a song when it isn't cached yet.
+	Fetch func(uri string) (Picture, error)
+
+	// Sizes lists the thumbnail sizes, in pixels, that Thumbnail()
+	// produces. Requests are rounded up to the nearest listed size, so
+	// that only a few thumbnails are kept per song. If empty, thumbnails
+	// are made at exactly the requested size.
+	Sizes []int
+
+	// KeepOriginals makes the cache keep full-size art after thumbnails
+	// have been made from it. Without it, only art requested through
+	// Get() stays cached at full size.
+	KeepOriginals bool
+
+	// MaxBytes limits the total size of the cached image data. Once it
+	// is exceeded, the least recently used pictures are dropped. Zero
+	// means no limit.
+	MaxBytes int64
+
+	lock     sync.Mutex
+	entries  map[artKey]*list.Element // of *artEntry, most recently used first in lru
+	lru      list.List
+	size     int64 // total bytes of image data cached
+	inflight map[artKey]*artCall
+}
+
+// artKey identifies a cached picture; size 0 is the original.
+type artKey struct {
+	uri  string
+	size int
+}
+
+type artEntry struct {
+	key artKey
+	pic Picture
+}
+
+// artCall is a picture being fetched or made, which others asking for
+// the same one wait for instead of repeating the work.
+type artCall struct {
+	done chan struct{}
+	pic  Picture
+	err  error
+}
+
+// NewArtCache() creates a cache that retrieves art with fetch.
+func NewArtCache(fetch func(uri string) (Picture, error)) *ArtCache {
+	return &ArtCache{Fetch: fetch}
+}
+
+// Get() returns the full-size art for a song.
+func (cache *ArtCache) Get(uri string) (Picture, error) {
+	return cache.get(uri, true)
+}
+
+func (cache *ArtCache) get(uri string, keep bool) (Picture, error) {
+	return cache.load(artKey{uri, 0}, keep || cache.KeepOriginals, func() (Picture, error) {
+		return cache.Fetch(uri)
+	})
+}
+
+// Thumbnail() returns the art for a song scaled down to fit within a
+// square of the given size, rounded up to one of the configured Sizes.
+// Art that is already small enough, or in a format that can't be
+// decoded, is returned unchanged.
+func (cache *ArtCache) Thumbnail(uri string, size int) (Picture, error) {
+	size = cache.roundSize(size)
+	return cache.load(artKey{uri, size}, true, func() (Picture, error) {
+		pic, err := cache.get(uri, false)
+		if err != nil {
+			return Picture{}, err
+		}
+		return scalePicture(pic, size), nil
+	})
+}
+
+// Forget() removes every cached picture for a song, such as after its art
+// has changed.
+func (cache *ArtCache) Forget(uri string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	for key, elem := range cache.entries {
+		if key.uri == uri {
+			cache.remove(elem)
+		}
+	}
+}
+
+// Purge() empties the cache.
+func (cache *ArtCache) Purge() {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.entries = nil
+	cache.lru.Init()
+	cache.size = 0
+}
+
+// load() returns the picture for key from the cache, or makes it with
+// fetch, storing it if keep is set. Concurrent loads of the same picture
+// share a single call to fetch.
+func (cache *ArtCache) load(key artKey, keep bool, fetch func() (Picture, error)) (Picture, error) {
+	cache.lock.Lock()
+	if elem, ok := cache.entries[key]; ok {
+		cache.lru.MoveToFront(elem)
+		cache.lock.Unlock()
+		return elem.Value.(*artEntry).pic, nil
+	}
+	if call, ok := cache.inflight[key]; ok {
+		cache.lock.Unlock()
+		<-call.done
+		return call.pic, call.err
+	}
+	call := &artCall{done: make(chan struct{})}
+	if cache.inflight == nil {
+		cache.inflight = map[artKey]*artCall{}
+	}
+	cache.inflight[key] = call
+	cache.lock.Unlock()
+
+	call.pic, call.err = fetch()
+
+	cache.lock.Lock()
+	delete(cache.inflight, key)
+	if call.err == nil && keep {
+		cache.store(key, call.pic)
+	}
+	cache.lock.Unlock()
+	close(call.done)
+	return call.pic, call.err
+}
+
+// store() caches a picture, evicting the least recently used ones to stay
+// within MaxBytes. It must be called with the lock held.
+func (cache *ArtCache) store(key artKey, pic Picture) {
+	if elem, ok := cache.entries[key]; ok {
+		cache.remove(elem)
+	}
+	size := int64(len(pic.Data))
+	if cache.MaxBytes > 0 && size > cache.MaxBytes {
+		return
+	}
+	if cache.entries == nil {
+		cache.entries = map[artKey]*list.Element{}
+	}
+	cache.entries[key] = cache.lru.PushFront(&artEntry{key, pic})
+	cache.size += size
+	for cache.MaxBytes > 0 && cache.size > cache.MaxBytes {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+// remove() drops a cached picture. It must be called with the lock held.
+func (cache *ArtCache) remove(elem *list.Element) {
+	entry := cache.lru.Remove(elem).(*artEntry)
+	delete(cache.entries, entry.key)
+	cache.size -= int64(len(entry.pic.Data))
+}
+
+// roundSize() rounds a requested size up to the nearest configured size,
+// or down to the largest one if it is bigger than all of them.
+func (cache *ArtCache) roundSize(size int) int {
+	if len(cache.Sizes) == 0 {
+		return size
+	}
+	sizes := append([]int(nil), cache.Sizes...)
+	sort.Ints(sizes)
+	for _, s := range sizes {
+		if s >= size {
+			return s
+		}
+	}
+	return sizes[len(sizes)-1]
+}
+
+// scalePicture() scales a picture down to fit within size x size. PNGs
+// stay PNGs to preserve transparency; everything else becomes a JPEG.
+func scalePicture(pic Picture, size int) Picture {
+	img, format, err := image.Decode(bytes.NewReader(pic.Data))
+	if err != nil || size <= 0 {
+		return pic
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return pic
+	}
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+	scaled := downscale(img, w, h)
+
+	var buf bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&buf, scaled)
+	} else {
+		err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return pic
+	}
+	return NewPicture(buf.Bytes(), "")
+}
+
+// downscale() shrinks an image to w x h by averaging the source pixels
+// that fall within each destination pixel.
+func downscale(src image.Image, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(bounds.Min.X+sx, bounds.Min.Y+sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
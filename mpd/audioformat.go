@@ -0,0 +1,119 @@
+package mpd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AudioFormat describes the format of audio data, as MPD writes it in
+// strings such as "44100:16:2", "48000:f:2" or "dsd64:2". Zero fields
+// stand for the "*" of formats that leave them unspecified, as in output
+// configurations.
+//
+// MPD writes DSD formats either as "dsd64:2" or as "352800:dsd:2", where
+// the rate is in bytes per second. Both parse to the same value, whose
+// SampleRate is in 1-bit samples per second, 2822400 for DSD64.
+type AudioFormat struct {
+	SampleRate int  // samples per second; for DSD, 1-bit samples per channel
+	Bits       int  // bits per sample of integer samples
+	Float      bool // samples are 32-bit floating point
+	DSD        bool // samples are 1-bit DSD
+	Channels   int
+}
+
+// dsdBaseRate is the rate that DSD rates such as DSD64 are multiples of.
+const dsdBaseRate = 44100
+
+// ParseAudioFormat() parses an audio format string.
+func ParseAudioFormat(s string) (AudioFormat, error) {
+	var format AudioFormat
+	invalid := fmt.Errorf("invalid audio format: %q", s)
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 && strings.HasPrefix(parts[0], "dsd") {
+		multiple, err := strconv.Atoi(parts[0][3:])
+		if err != nil || multiple <= 0 {
+			return AudioFormat{}, invalid
+		}
+		format.SampleRate = multiple * dsdBaseRate
+		format.DSD = true
+		parts = []string{"*", "*", parts[1]}
+	} else if len(parts) != 3 {
+		return AudioFormat{}, invalid
+	} else if parts[0] != "*" {
+		rate, err := strconv.Atoi(parts[0])
+		if err != nil || rate <= 0 {
+			return AudioFormat{}, invalid
+		}
+		format.SampleRate = rate
+	}
+	switch parts[1] {
+	case "*":
+	case "f":
+		format.Float = true
+	case "dsd":
+		format.DSD = true
+		// The rate is in bytes, each holding eight samples.
+		format.SampleRate *= 8
+	default:
+		bits, err := strconv.Atoi(parts[1])
+		if err != nil || bits <= 0 {
+			return AudioFormat{}, invalid
+		}
+		format.Bits = bits
+	}
+	if parts[2] != "*" {
+		channels, err := strconv.Atoi(parts[2])
+		if err != nil || channels <= 0 {
+			return AudioFormat{}, invalid
+		}
+		format.Channels = channels
+	}
+	return format, nil
+}
+
+// String() formats the audio format the way MPD does, so that it parses
+// back to the same value.
+func (f AudioFormat) String() string {
+	channels := "*"
+	if f.Channels > 0 {
+		channels = strconv.Itoa(f.Channels)
+	}
+	if f.DSD && f.SampleRate > 0 && f.SampleRate%dsdBaseRate == 0 {
+		return fmt.Sprintf("dsd%d:%s", f.SampleRate/dsdBaseRate, channels)
+	}
+	rate := "*"
+	if f.DSD && f.SampleRate > 0 {
+		rate = strconv.Itoa(f.SampleRate / 8)
+	} else if f.SampleRate > 0 {
+		rate = strconv.Itoa(f.SampleRate)
+	}
+	bits := "*"
+	switch {
+	case f.Float:
+		bits = "f"
+	case f.DSD:
+		bits = "dsd"
+	case f.Bits > 0:
+		bits = strconv.Itoa(f.Bits)
+	}
+	return rate + ":" + bits + ":" + channels
+}
+
+// IsZero() reports whether the format is entirely unspecified.
+func (f AudioFormat) IsZero() bool {
+	return f == AudioFormat{}
+}
+
+func (f AudioFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f *AudioFormat) UnmarshalText(text []byte) error {
+	format, err := ParseAudioFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
@@ -0,0 +1,51 @@
+package mpd
+
+import (
+	"testing"
+)
+
+func TestParseAudioFormat(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   AudioFormat
+		string string // what String() gives back
+	}{
+		{"44100:16:2", AudioFormat{SampleRate: 44100, Bits: 16, Channels: 2}, "44100:16:2"},
+		{"96000:24:6", AudioFormat{SampleRate: 96000, Bits: 24, Channels: 6}, "96000:24:6"},
+		{"48000:f:2", AudioFormat{SampleRate: 48000, Float: true, Channels: 2}, "48000:f:2"},
+		{"*:*:*", AudioFormat{}, "*:*:*"},
+		{"*:16:*", AudioFormat{Bits: 16}, "*:16:*"},
+		{"dsd64:2", AudioFormat{SampleRate: 2822400, DSD: true, Channels: 2}, "dsd64:2"},
+		{"dsd512:2", AudioFormat{SampleRate: 22579200, DSD: true, Channels: 2}, "dsd512:2"},
+		{"352800:dsd:2", AudioFormat{SampleRate: 2822400, DSD: true, Channels: 2}, "dsd64:2"},
+		{"1000:dsd:1", AudioFormat{SampleRate: 8000, DSD: true, Channels: 1}, "1000:dsd:1"},
+		{"*:dsd:*", AudioFormat{DSD: true}, "*:dsd:*"},
+	}
+	for _, test := range tests {
+		got, err := ParseAudioFormat(test.s)
+		if err != nil {
+			t.Errorf("ParseAudioFormat(%q): %v", test.s, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseAudioFormat(%q) = %+v, want %+v", test.s, got, test.want)
+		}
+		if s := got.String(); s != test.string {
+			t.Errorf("ParseAudioFormat(%q).String() = %q, want %q", test.s, s, test.string)
+		}
+		if again, err := ParseAudioFormat(got.String()); err != nil || again != got {
+			t.Errorf("ParseAudioFormat(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+}
+
+func TestParseAudioFormatInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "44100", "44100:16", "44100:16:2:1", "x:16:2", "0:16:2",
+		"44100:x:2", "44100:0:2", "44100:16:0", "dsd:2", "dsdx:2", "dsd0:2",
+	} {
+		if got, err := ParseAudioFormat(s); err == nil {
+			t.Errorf("ParseAudioFormat(%q) = %+v, want error", s, got)
+		}
+	}
+}
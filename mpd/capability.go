@@ -0,0 +1,59 @@
+package mpd
+
+// commandVersions lists the protocol versions that introduced commands
+// applications commonly check for, which Supports() falls back on when
+// the server won't list its commands.
+var commandVersions = map[string]Version{
+	"albumart":    {0, 21, 0},
+	"readpicture": {0, 22, 0},
+	"binarylimit": {0, 22, 4},
+	"getvol":      {0, 23, 0},
+}
+
+// Commands() returns the commands the server allows this connection to
+// use, which depends on its version and on the password sent, if any.
+func (conn *Conn) Commands() ([]string, error) {
+	lines, err := conn.exec("commands")
+	if err != nil {
+		return nil, err
+	}
+	var commands []string
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "command" {
+			commands = append(commands, value)
+		}
+	}
+	return commands, nil
+}
+
+// Supports() reports whether the server offers a command, such as
+// "readpicture" or "newpartition", so that applications can enable
+// features based on what the server actually allows. The list of commands
+// is fetched once and cached until the connection is re-established or a
+// password is sent. If the server refuses to list its commands, Supports()
+// falls back on the protocol version for the commands it knows about.
+func (conn *Conn) Supports(cmd string) (bool, error) {
+	conn.stateLock.Lock()
+	commands := conn.commands
+	conn.stateLock.Unlock()
+
+	if commands == nil {
+		list, err := conn.Commands()
+		if err != nil {
+			if _, ok := AckCode(err); ok {
+				if since, ok := commandVersions[cmd]; ok {
+					return conn.ProtocolVersion().AtLeast(since.Major, since.Minor, since.Patch), nil
+				}
+			}
+			return false, err
+		}
+		commands = make(map[string]bool, len(list))
+		for _, name := range list {
+			commands[name] = true
+		}
+		conn.stateLock.Lock()
+		conn.commands = commands
+		conn.stateLock.Unlock()
+	}
+	return commands[cmd], nil
+}
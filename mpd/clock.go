@@ -0,0 +1,64 @@
+package mpd
+
+import (
+	"time"
+)
+
+// Clock provides the time functions used by the time-based helpers in
+// this package, such as StatsTracker and FingerprintJob. Substituting a
+// fake lets their behavior be tested without real sleeps.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the subset of time.Ticker used through a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock backed by the time package. It is used
+// wherever no other Clock is given.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// WithClock() sets the Clock used by the connection's own time-based
+// helpers, such as keepalive pings, FadeTo(), ScheduleAt() and
+// SleepAfter(). It defaults to SystemClock. The testutil package has a
+// Clock that only moves when told to.
+func WithClock(clock Clock) Option {
+	return func(opts *options) {
+		opts.clock = clock
+	}
+}
+
+// clockOrSystem() returns clock, or SystemClock if it is nil.
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
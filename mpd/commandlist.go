@@ -0,0 +1,166 @@
+package mpd
+
+import (
+	"strconv"
+)
+
+// CommandList queues commands to be sent together in a single command
+// list, which the server runs atomically: no other client sees the state
+// in between, and the list stops at the first command that fails.
+//
+//	cl := conn.NewCommandList()
+//	cl.Clear()
+//	first := cl.AddID(uri)
+//	cl.Play(0)
+//	if err := cl.Execute(); err != nil {
+//		return err
+//	}
+//	id, _ := first.ID()
+type CommandList struct {
+	conn      *Conn
+	cmds      []string
+	results   map[int]*PendingID // by the index of the command that yields them
+	responses []Response         // from the last Execute()
+}
+
+// PendingID is the id of a song added by a command list, which is known
+// once the list has been executed.
+type PendingID struct {
+	id   SongID
+	done bool
+}
+
+// ID() returns the id of the song, or false if the command list hasn't
+// been executed or failed before adding it.
+func (p *PendingID) ID() (SongID, bool) {
+	return p.id, p.done
+}
+
+// NewCommandList() starts a command list to be sent on this connection.
+func (conn *Conn) NewCommandList() *CommandList {
+	return &CommandList{conn: conn}
+}
+
+// Len() returns the number of commands queued.
+func (cl *CommandList) Len() int {
+	return len(cl.cmds)
+}
+
+// Command() queues an arbitrary command, quoting its arguments as
+// FormatCommand() does. An argument with a line break in it makes
+// Execute() fail with ErrLineBreak.
+func (cl *CommandList) Command(name string, args ...string) {
+	cl.cmds = append(cl.cmds, formatCommand(name, args...))
+}
+
+// Add() queues adding a song, or a directory recursively, to the end of
+// the queue.
+func (cl *CommandList) Add(uri string) {
+	cl.Command("add", uri)
+}
+
+// AddID() queues adding a song to the end of the queue, returning its id
+// once the list has been executed.
+func (cl *CommandList) AddID(uri string) *PendingID {
+	cl.Command("addid", uri)
+	return cl.pendingID()
+}
+
+// AddIDAt() is like AddID(), but adds the song at the given position.
+func (cl *CommandList) AddIDAt(uri string, pos int) *PendingID {
+	cl.Command("addid", uri, strconv.Itoa(pos))
+	return cl.pendingID()
+}
+
+func (cl *CommandList) pendingID() *PendingID {
+	if cl.results == nil {
+		cl.results = make(map[int]*PendingID)
+	}
+	p := &PendingID{}
+	cl.results[len(cl.cmds)-1] = p
+	return p
+}
+
+// Delete() queues removing the song at the given position from the queue.
+func (cl *CommandList) Delete(pos int) {
+	cl.Command("delete", strconv.Itoa(pos))
+}
+
+// DeleteID() queues removing the song with the given id from the queue.
+func (cl *CommandList) DeleteID(id SongID) {
+	cl.Command("deleteid", strconv.Itoa(int(id)))
+}
+
+// Move() queues moving the song at one position of the queue to another.
+func (cl *CommandList) Move(from, to int) {
+	cl.Command("move", strconv.Itoa(from), strconv.Itoa(to))
+}
+
+// MoveID() queues moving the song with the given id to a position.
+func (cl *CommandList) MoveID(id SongID, to int) {
+	cl.Command("moveid", strconv.Itoa(int(id)), strconv.Itoa(to))
+}
+
+// Clear() queues removing every song from the queue.
+func (cl *CommandList) Clear() {
+	cl.Command("clear")
+}
+
+// Shuffle() queues shuffling the queue.
+func (cl *CommandList) Shuffle() {
+	cl.Command("shuffle")
+}
+
+// Load() queues adding the songs of a stored playlist to the queue.
+func (cl *CommandList) Load(name string) {
+	cl.Command("load", name)
+}
+
+// Play() queues playing the song at the given position of the queue.
+func (cl *CommandList) Play(pos int) {
+	cl.Command("play", strconv.Itoa(pos))
+}
+
+// PlayID() queues playing the song with the given id.
+func (cl *CommandList) PlayID(id SongID) {
+	cl.Command("playid", strconv.Itoa(int(id)))
+}
+
+// Pause() queues pausing or resuming playback.
+func (cl *CommandList) Pause(pause bool) {
+	cl.Command("pause", binaryBool(pause))
+}
+
+// Stop() queues stopping playback.
+func (cl *CommandList) Stop() {
+	cl.Command("stop")
+}
+
+// Responses() returns the responses to the commands run by the last call
+// to Execute(), in order. If a command failed, only the responses to the
+// commands before it are included.
+func (cl *CommandList) Responses() []Response {
+	return cl.responses
+}
+
+// Execute() sends the queued commands and fills in their results. If a
+// command fails, the commands after it aren't run, and its error is
+// returned. For errors from the server, that is an *AckError whose Index
+// is the position of the failed command in the list, as counted by
+// Len(). The results of the commands before it are still filled in.
+// The list is left as it is, so it may be executed again.
+func (cl *CommandList) Execute() error {
+	if len(cl.cmds) == 0 {
+		return nil
+	}
+	results, _, err := cl.conn.SendListOK(cl.cmds)
+	cl.responses = results
+	for i, resp := range results {
+		if p, ok := cl.results[i]; ok {
+			if id, err := strconv.Atoi(resp.Get("Id")); err == nil {
+				p.id, p.done = SongID(id), true
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package mpd
+
+import (
+	"path/filepath"
+)
+
+// ServerConfig holds the parts of the server's configuration it reveals
+// to local clients.
+type ServerConfig struct {
+	MusicDirectory    string // the root of the database on the filesystem
+	PlaylistDirectory string // where stored playlists are kept, if set
+	PCRE              bool   // true if filters support regular expressions
+}
+
+// Config() returns the server's configuration. The server only answers on
+// local connections, such as over a Unix socket, and refuses elsewhere.
+func (conn *Conn) Config() (ServerConfig, error) {
+	attrs, err := conn.attrs("config")
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	return ServerConfig{
+		MusicDirectory:    attrs["music_directory"],
+		PlaylistDirectory: attrs["playlist_directory"],
+		PCRE:              attrs["pcre"] == "1",
+	}, nil
+}
+
+// LocalPath() returns the path on the filesystem of a song in the
+// database, such as for editing its tags. It returns the empty string if
+// the music directory is unknown.
+func (config ServerConfig) LocalPath(uri string) string {
+	if config.MusicDirectory == "" {
+		return ""
+	}
+	return filepath.Join(config.MusicDirectory, filepath.FromSlash(uri))
+}
@@ -0,0 +1,365 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// Option configures a connection made by ConnectWithOptions().
+type Option func(*options)
+
+type options struct {
+	network      string
+	timeout      time.Duration
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	password     string
+	bufferSize   int
+	reconnect    *ReconnectPolicy
+	keepAlive    time.Duration
+	dialer       Dialer
+	tls          *tls.Config
+	binaryLimit  int
+	logger       *slog.Logger
+	resumeState  string
+	clock        Clock
+}
+
+// Default timeouts used unless overridden with WithDialTimeout(),
+// WithReadTimeout() or WithWriteTimeout().
+const (
+	DefaultDialTimeout  = 10 * time.Second
+	DefaultReadTimeout  = 60 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+func defaultOptions() options {
+	return options{
+		dialTimeout:  DefaultDialTimeout,
+		readTimeout:  DefaultReadTimeout,
+		writeTimeout: DefaultWriteTimeout,
+	}
+}
+
+// WithNetwork() sets the network to dial, such as "tcp" or "unix". By
+// default, addresses starting with "/" are dialed as Unix sockets, those
+// starting with "@" as Linux abstract sockets, such as the "@mpd" used by
+// some socket-activated setups, and anything else over TCP.
+func WithNetwork(network string) Option {
+	return func(opts *options) {
+		opts.network = network
+	}
+}
+
+// Dialer establishes network connections. It is satisfied by *net.Dialer,
+// by the SOCKS dialers of golang.org/x/net/proxy, and by anything else
+// with a DialContext() method, so proxies, custom name resolution or
+// instrumentation can be plugged in with WithDialer().
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// DialerFunc adapts a function to the Dialer interface.
+type DialerFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (f DialerFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// WithDialer() makes connections through the given dialer, such as one
+// that tunnels them through SSH or a proxy, instead of dialing directly.
+// The network and address are passed to it as they are, and the dial
+// timeout is applied through the context. Without it, connections are
+// made with a net.Dialer.
+func WithDialer(dialer Dialer) Option {
+	return func(opts *options) {
+		opts.dialer = dialer
+	}
+}
+
+// WithTLS() performs a TLS handshake before the MPD one, for servers
+// exposed behind a TLS terminator such as stunnel or haproxy. Unless the
+// config sets ServerName, the host part of the address is used both for
+// SNI and to verify the server's certificate. Verification can be
+// customized through the config as usual, with RootCAs to trust a private
+// CA or VerifyPeerCertificate to pin a certificate. A nil config uses the
+// defaults.
+func WithTLS(config *tls.Config) Option {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	return func(opts *options) {
+		opts.tls = config
+	}
+}
+
+// WithTimeout() bounds how long connecting, including the handshake and
+// authentication, may take.
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.timeout = timeout
+	}
+}
+
+// WithDialTimeout() bounds how long establishing the network connection
+// may take. Zero disables the timeout.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.dialTimeout = timeout
+	}
+}
+
+// WithReadTimeout() bounds how long the server may go quiet while the
+// response to a command is being read. The timeout starts over with every
+// line received, so long responses, such as listallinfo on a large
+// library, aren't cut off as long as they keep coming, and neither are
+// slow consumers of streamed responses. It doesn't apply to idle, which
+// waits as long as it takes, or to commands bound by a context with a
+// deadline or by WithTimeout() on a view, for which the deadline applies
+// instead. Zero disables the timeout.
+//
+// A command that times out leaves the rest of its response unread, so the
+// connection is closed.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout() bounds how long sending a command may take. Zero
+// disables the timeout.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.writeTimeout = timeout
+	}
+}
+
+// WithPassword() sends a password right after connecting.
+func WithPassword(password string) Option {
+	return func(opts *options) {
+		opts.password = password
+	}
+}
+
+// WithBufferSize() sets the size of the buffers used for reading and
+// writing. It only affects performance: response lines of any length are
+// read in full, though those longer than the buffer take extra copying.
+func WithBufferSize(size int) Option {
+	return func(opts *options) {
+		opts.bufferSize = size
+	}
+}
+
+// ConnectWithOptions() connects to a running MPD instance, configured by
+// the given options.
+func ConnectWithOptions(addr string, opts ...Option) (*Conn, error) {
+	return ConnectContext(context.Background(), addr, opts...)
+}
+
+// ConnectContext() is like ConnectWithOptions(), but gives up when ctx is
+// done. Once connected, the context no longer has any effect.
+func ConnectContext(ctx context.Context, addr string, opts ...Option) (*Conn, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &session{addrs: []string{addr}, opts: o}
+	if err := s.connect(ctx); err != nil {
+		return nil, err
+	}
+	return s.newConn().connected(ctx)
+}
+
+// networkFor() returns the network an address is dialed on unless
+// WithNetwork() says otherwise.
+func networkFor(addr string) string {
+	if strings.HasPrefix(addr, "/") || strings.HasPrefix(addr, "@") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+// newConn() returns a Conn for a session that has just connected.
+func (s *session) newConn() *Conn {
+	s.state = ConnEvent{State: StateConnected}
+	if s.opts.password != "" {
+		// start() has sent it already.
+		s.state = ConnEvent{State: StateAuthenticated}
+	}
+	conn := &Conn{session: s}
+	if s.opts.keepAlive > 0 {
+		go conn.keepAlive(s.opts.keepAlive)
+	}
+	return conn
+}
+
+// connect() dials the server, performs the handshake and authenticates,
+// replacing the session's socket. If there are several addresses, they are
+// tried in order until one works. It is used both to make the initial
+// connection and to reconnect, and must be called with the lock held once
+// the session is in use.
+func (s *session) connect(ctx context.Context) error {
+	if s.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.timeout)
+		defer cancel()
+	}
+	var errs []error
+	for _, addr := range s.addrs {
+		err := s.connectTo(ctx, addr)
+		if err == nil {
+			s.stateLock.Lock()
+			s.addr = addr
+			s.stateLock.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(s.addrs) == 1 {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *session) connectTo(ctx context.Context, addr string) error {
+	socket, err := s.dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if s.opts.tls != nil {
+		if socket, err = s.tlsHandshake(ctx, socket, addr); err != nil {
+			return err
+		}
+	}
+	return s.start(ctx, socket)
+}
+
+// tlsHandshake() wraps the socket in TLS, verifying the server against the
+// host part of the address unless the config names a server already.
+func (s *session) tlsHandshake(ctx context.Context, socket net.Conn, addr string) (net.Conn, error) {
+	config := s.opts.tls.Clone()
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		config.ServerName = host
+	}
+	tlsSocket := tls.Client(socket, config)
+	if err := tlsSocket.HandshakeContext(ctx); err != nil {
+		socket.Close()
+		return nil, err
+	}
+	return tlsSocket, nil
+}
+
+// dial() establishes the network connection, using the dialer given by
+// WithDialer() if there is one.
+func (s *session) dial(ctx context.Context, addr string) (net.Conn, error) {
+	network := s.opts.network
+	if network == "" {
+		network = networkFor(addr)
+	}
+	if s.opts.dialer == nil {
+		dialer := net.Dialer{Timeout: s.opts.dialTimeout}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if s.opts.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.dialTimeout)
+		defer cancel()
+	}
+	return s.opts.dialer.DialContext(ctx, network, addr)
+}
+
+// start() performs the handshake and authenticates over a newly
+// established socket, closing it if that fails or ctx is done first.
+func (s *session) start(ctx context.Context, socket net.Conn) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		socket.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		socket.SetDeadline(aLongTimeAgo)
+	})
+	err := s.handshake(socket)
+	if err == nil && s.opts.password != "" {
+		cmd := "password " + quote(s.opts.password)
+		if err = checkLines(cmd); err == nil {
+			_, _, err = s.roundTrip(nil, time.Time{}, cmd)
+		}
+		if code, ok := AckCode(err); ok && code == ACK_ERROR_PASSWORD {
+			err = ErrPassword
+		}
+	}
+	if err == nil {
+		err = s.negotiateBinaryLimit()
+	}
+	if !stop() && err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		socket.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	socket.SetDeadline(time.Time{})
+	return nil
+}
+
+// handshake() reads the server's greeting and sets up the session to use
+// the socket.
+func (s *session) handshake(socket net.Conn) error {
+	// The socket is also guarded by stateLock, so that Close() can get at
+	// it while a command is in progress.
+	s.stateLock.Lock()
+	closed := s.closed
+	if !closed {
+		s.socket = socket
+		s.commands = nil
+	}
+	s.stateLock.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if s.opts.bufferSize > 0 {
+		s.in = bufio.NewReaderSize(socket, s.opts.bufferSize)
+		s.out = bufio.NewWriterSize(socket, s.opts.bufferSize)
+	} else {
+		s.in = bufio.NewReader(socket)
+		s.out = bufio.NewWriter(socket)
+	}
+	resp, err := s.readLine()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	s.logReceived(resp)
+	if !strings.HasPrefix(resp, "OK MPD ") {
+		return fmt.Errorf("unexpected MPD response: '%s'", resp)
+	}
+	version := resp[7:]
+	if version == "" {
+		return errors.New("MPD reported empty version number")
+	}
+	s.stateLock.Lock()
+	s.version = version
+	s.stateLock.Unlock()
+	s.touch()
+	return nil
+}
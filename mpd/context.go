@@ -0,0 +1,126 @@
+package mpd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+// WithContext() returns a view of the connection whose commands are all
+// bound by ctx: a command fails with the context's error if it is done
+// before the command is sent, and a command in progress is interrupted
+// when it is canceled or its deadline passes. Interrupting an idle ends
+// it cleanly with noidle, but interrupting any other command leaves the
+// rest of its response unread, so the connection is closed.
+//
+// The view shares everything else with the original connection, and the
+// original is unaffected by ctx.
+func (conn *Conn) WithContext(ctx context.Context) *Conn {
+	view := *conn
+	view.ctx = ctx
+	return &view
+}
+
+// WithTimeout() returns a view of the connection that gives each command
+// at most the given time to respond, instead of the read timeout set when
+// connecting, so that quick commands can fail fast while others, such as
+// listallinfo on a huge library, are given longer:
+//
+//	status, err := conn.WithTimeout(2 * time.Second).Status()
+//
+// A command that times out leaves the rest of its response unread, so the
+// connection is closed. The timeout doesn't apply to idle, which can be
+// bounded with WithContext() instead.
+func (conn *Conn) WithTimeout(timeout time.Duration) *Conn {
+	view := *conn
+	view.timeout = timeout
+	view.deadline = time.Time{}
+	return &view
+}
+
+// WithDeadline() is like WithTimeout(), but every command must have
+// responded by the given time.
+func (conn *Conn) WithDeadline(deadline time.Time) *Conn {
+	view := *conn
+	view.deadline = deadline
+	view.timeout = 0
+	return &view
+}
+
+// commandDeadline() returns the deadline for the response to cmd set by
+// WithTimeout() or WithDeadline(), or the zero time if there is none.
+func (conn *Conn) commandDeadline(cmd string) time.Time {
+	if strings.HasPrefix(cmd, "idle") {
+		return time.Time{}
+	}
+	if conn.timeout > 0 {
+		return time.Now().Add(conn.timeout)
+	}
+	return conn.deadline
+}
+
+// SendContext() is like Send(), but bound by ctx as described for
+// WithContext().
+func (conn *Conn) SendContext(ctx context.Context, cmd string) (Response, error) {
+	return conn.WithContext(ctx).Send(cmd)
+}
+
+// contextError() returns the context's error if it caused err, which may
+// be a timeout that fired slightly before the context noticed its
+// deadline had passed. It returns nil if ctx is nil or not involved.
+func contextError(ctx context.Context, err error) error {
+	if ctx == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		if _, ok := ctx.Deadline(); ok {
+			return context.DeadlineExceeded
+		}
+	}
+	return nil
+}
+
+// contextDeadline() returns the deadline of ctx, if it is non-nil and
+// has one.
+func contextDeadline(ctx context.Context) (time.Time, bool) {
+	if ctx == nil {
+		return time.Time{}, false
+	}
+	return ctx.Deadline()
+}
+
+// aLongTimeAgo is a deadline in the past, for interrupting blocked reads.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// watchContext() arranges for the response to cmd, which has just been
+// sent, to be interrupted when ctx is done. It returns a function
+// that undoes this once the response has been read. It must be called
+// with the lock held.
+func (s *session) watchContext(ctx context.Context, cmd string) func() {
+	idle := strings.HasPrefix(cmd, "idle")
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline && !idle {
+		s.socket.SetReadDeadline(deadline)
+	}
+	done := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(done)
+		if idle {
+			s.interruptIdle()
+		} else {
+			s.socket.SetReadDeadline(aLongTimeAgo)
+		}
+	})
+	return func() {
+		if !stop() {
+			<-done
+		}
+		if hasDeadline || ctx.Err() != nil {
+			s.socket.SetReadDeadline(time.Time{})
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package mpd
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Decode() stores the pairs of a response in the fields of the struct
+// target points to, so that applications can define their own lean
+// views of songs, status and the like:
+//
+//	type Track struct {
+//		File   string        `mpd:"file"`
+//		Artist []string      `mpd:"Artist"`
+//		Length time.Duration `mpd:"duration"`
+//	}
+//
+// Fields are matched to keys by their mpd tag, or by their name if they
+// have none, and those tagged "-" are skipped. Keys without a field are
+// ignored. Fields may be strings, integers, floats, booleans sent as "1"
+// or "0", durations sent as seconds, times in RFC 3339 format, or any
+// type implementing encoding.TextUnmarshaler. A slice field collects
+// every value of its key; other fields take the first.
+//
+// If target points to a slice of structs instead, the response is split
+// into records, each starting at a pair with the same key as the first
+// pair of the response, and each record is decoded into an element.
+func Decode(resp Response, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("decode target must be a non-nil pointer")
+	}
+	v = v.Elem()
+	switch {
+	case v.Kind() == reflect.Struct:
+		return decodeStruct(resp, v)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		if len(resp) == 0 {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			return nil
+		}
+		records := resp.Records(resp[0].Key)
+		slice := reflect.MakeSlice(v.Type(), len(records), len(records))
+		for i, record := range records {
+			if err := decodeStruct(record, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return fmt.Errorf("can't decode into %s", v.Type())
+}
+
+// Exec() sends a command and decodes its response into a T, which must
+// be a struct or a slice of structs, as described for Decode():
+//
+//	outputs, err := mpd.Exec[[]MyOutput](conn, "outputs")
+func Exec[T any](conn *Conn, cmd string) (T, error) {
+	var target T
+	resp, err := conn.Send(cmd)
+	if err != nil {
+		return target, err
+	}
+	err = Decode(resp, &target)
+	return target, err
+}
+
+// decodeFields caches the fields of struct types by key.
+var decodeFields sync.Map // reflect.Type -> map[string][]int
+
+// fieldsByKey() returns the indexes of the fields of a struct type by
+// the keys they are decoded from, looking into embedded structs.
+func fieldsByKey(t reflect.Type) map[string][]int {
+	if fields, ok := decodeFields.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && isStructOrPointer(f.Type) {
+			continue
+		}
+		key := f.Tag.Get("mpd")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		if _, ok := fields[key]; !ok {
+			fields[key] = f.Index
+		}
+	}
+	decodeFields.Store(t, fields)
+	return fields
+}
+
+// isStructOrPointer() reports whether t is a struct or a pointer to one,
+// whose fields are promoted when embedded.
+func isStructOrPointer(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// fieldByIndex() is like reflect.Value.FieldByIndex(), but allocates the
+// embedded struct pointers on the way to the field, failing for those it
+// can't set.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("can't set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func decodeStruct(resp Response, v reflect.Value) error {
+	fields := fieldsByKey(v.Type())
+	seen := make(map[string]bool)
+	for _, kv := range resp {
+		index, ok := fields[kv.Key]
+		if !ok {
+			continue
+		}
+		field, err := fieldByIndex(v, index)
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", kv.Key, err)
+		}
+		if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) &&
+			!reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := decodeValue(kv.Value, elem); err != nil {
+				return fmt.Errorf("decoding %s: %w", kv.Key, err)
+			}
+			if !seen[kv.Key] {
+				field.Set(reflect.MakeSlice(field.Type(), 0, 1))
+			}
+			field.Set(reflect.Append(field, elem))
+		} else if !seen[kv.Key] {
+			if err := decodeValue(kv.Value, field); err != nil {
+				return fmt.Errorf("decoding %s: %w", kv.Key, err)
+			}
+		}
+		seen[kv.Key] = true
+	}
+	return nil
+}
+
+// decodeValue() parses a value into v according to its type.
+func decodeValue(s string, v reflect.Value) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Type() {
+	case durationType:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(f * float64(time.Second)))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decodedSong struct {
+	File     string        `mpd:"file"`
+	Artist   []string      `mpd:"Artist"`
+	Duration time.Duration `mpd:"duration"`
+	Pos      int
+	Id       SongID
+	Modified time.Time   `mpd:"Last-Modified"`
+	Format   AudioFormat `mpd:"Format"`
+	Skipped  string      `mpd:"-"`
+}
+
+type decodedOutput struct {
+	ID      int    `mpd:"outputid"`
+	Name    string `mpd:"outputname"`
+	Enabled bool   `mpd:"outputenabled"`
+	Volume  float64
+}
+
+type decodedEmbedded struct {
+	File string `mpd:"file"`
+	*decodedTags
+	Extra
+}
+
+type decodedTags struct {
+	Title string
+}
+
+type Extra struct {
+	Album string
+}
+
+type DecodedPointer struct {
+	File string `mpd:"file"`
+	*Extra
+}
+
+func TestDecode(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		resp   Response
+		target any
+		want   any
+	}{
+		{
+			Response{
+				{"file", "a.flac"}, {"Artist", "One"}, {"Artist", "Two"},
+				{"duration", "61.500"}, {"Pos", "3"}, {"Id", "7"},
+				{"Last-Modified", "2024-03-01T12:00:00Z"}, {"Format", "44100:16:2"},
+				{"Skipped", "x"}, {"Unknown", "y"}, {"file", "ignored.flac"},
+			},
+			&decodedSong{},
+			&decodedSong{
+				File: "a.flac", Artist: []string{"One", "Two"}, Duration: 61500 * time.Millisecond,
+				Pos: 3, Id: 7, Modified: modified,
+				Format: AudioFormat{SampleRate: 44100, Bits: 16, Channels: 2},
+			},
+		},
+		{
+			Response{
+				{"outputid", "0"}, {"outputname", "ALSA"}, {"outputenabled", "1"},
+				{"outputid", "1"}, {"outputname", "HTTP"}, {"outputenabled", "0"}, {"Volume", "0.5"},
+			},
+			&[]decodedOutput{},
+			&[]decodedOutput{{0, "ALSA", true, 0}, {1, "HTTP", false, 0.5}},
+		},
+		{Response{}, &[]decodedOutput{{ID: 1}}, &[]decodedOutput{}},
+		{
+			Response{{"file", "a.flac"}, {"Album", "B"}},
+			&DecodedPointer{},
+			&DecodedPointer{File: "a.flac", Extra: &Extra{Album: "B"}},
+		},
+		{
+			Response{{"file", "a.flac"}, {"Album", "B"}},
+			&decodedEmbedded{},
+			&decodedEmbedded{File: "a.flac", Extra: Extra{Album: "B"}},
+		},
+	}
+	for _, test := range tests {
+		if err := Decode(test.resp, test.target); err != nil {
+			t.Errorf("Decode(%v): %v", test.resp, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.target, test.want) {
+			t.Errorf("Decode(%v) = %+v, want %+v", test.resp, test.target, test.want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		resp   Response
+		target any
+	}{
+		{Response{}, decodedSong{}},
+		{Response{}, (*decodedSong)(nil)},
+		{Response{}, new(int)},
+		{Response{{"Pos", "x"}}, &decodedSong{}},
+		{Response{{"duration", "x"}}, &decodedSong{}},
+		{Response{{"Last-Modified", "yesterday"}}, &decodedSong{}},
+		{Response{{"Format", "bad"}}, &decodedSong{}},
+		{Response{{"outputenabled", "maybe"}}, &decodedOutput{}},
+		// An unexported embedded pointer can't be allocated.
+		{Response{{"Title", "T"}}, &decodedEmbedded{}},
+	}
+	for _, test := range tests {
+		if err := Decode(test.resp, test.target); err == nil {
+			t.Errorf("Decode(%v, %T) succeeded, want error", test.resp, test.target)
+		}
+	}
+}
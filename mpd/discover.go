@@ -0,0 +1,260 @@
+package mpd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscoveredServer is an MPD server found on the local network by
+// Discover().
+type DiscoveredServer struct {
+	Name  string   // the instance name, such as "Music Player @ musicbox"
+	Host  string   // the host name, such as "musicbox.local"
+	Port  int      // the port MPD listens on
+	Addrs []net.IP // the host's addresses, if they were announced
+}
+
+// Addr() returns an address to connect to the server with, preferring an
+// announced IP address over the host name.
+func (s DiscoveredServer) Addr() string {
+	host := strings.TrimSuffix(s.Host, ".")
+	if len(s.Addrs) > 0 {
+		host = s.Addrs[0].String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(s.Port))
+}
+
+const (
+	mdnsService  = "_mpd._tcp.local"
+	mdnsAddr     = "224.0.0.251:5353"
+	mdnsInterval = time.Second
+
+	dnsTypeA    = 1
+	dnsTypePTR  = 12
+	dnsTypeAAAA = 28
+	dnsTypeSRV  = 33
+	dnsClassIN  = 1
+)
+
+// Discover() looks for MPD servers advertising themselves with Zeroconf,
+// as MPD does through Avahi or Bonjour under the _mpd._tcp service type.
+// It listens for answers until ctx is done, so ctx should have a timeout
+// of a second or two, and then returns the servers that answered, sorted
+// by name.
+func Discover(ctx context.Context) ([]DiscoveredServer, error) {
+	group, err := net.ResolveUDPAddr("udp4", mdnsAddr)
+	if err != nil {
+		return nil, err
+	}
+	// Querying from a port other than 5353 asks responders to answer
+	// directly, so there's no need to join the multicast group.
+	socket, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer socket.Close()
+	stop := context.AfterFunc(ctx, func() {
+		socket.SetReadDeadline(aLongTimeAgo)
+	})
+	defer stop()
+
+	query := mdnsQuery(mdnsService)
+	found := newMDNSResults()
+	buf := make([]byte, 9000)
+	for ctx.Err() == nil {
+		if _, err := socket.WriteToUDP(query, group); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(mdnsInterval)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		socket.SetReadDeadline(deadline)
+		for ctx.Err() == nil {
+			n, _, err := socket.ReadFromUDP(buf)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			// Malformed packets from other responders are ignored.
+			found.parse(buf[:n])
+		}
+	}
+	return found.servers(), nil
+}
+
+// mdnsQuery() builds a query for the PTR records of a service type.
+func mdnsQuery(service string) []byte {
+	msg := make([]byte, 12, 64)
+	binary.BigEndian.PutUint16(msg[4:], 1) // one question
+	for _, label := range strings.Split(service, ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypePTR)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	return msg
+}
+
+type mdnsSRV struct {
+	target string
+	port   int
+}
+
+// mdnsResults collects the records of interest from mDNS responses. DNS
+// names are case-insensitive, so the maps are keyed by lowercased names.
+type mdnsResults struct {
+	instances map[string]string // the instance name as announced
+	srv       map[string]mdnsSRV
+	addrs     map[string][]net.IP
+}
+
+func newMDNSResults() *mdnsResults {
+	return &mdnsResults{
+		instances: make(map[string]string),
+		srv:       make(map[string]mdnsSRV),
+		addrs:     make(map[string][]net.IP),
+	}
+}
+
+var errMalformedDNS = errors.New("malformed DNS message")
+
+// parse() records the PTR, SRV, A and AAAA records of a response.
+func (r *mdnsResults) parse(msg []byte) error {
+	if len(msg) < 12 {
+		return errMalformedDNS
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	records := int(binary.BigEndian.Uint16(msg[6:])) +
+		int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+	off := 12
+	for i := 0; i < questions; i++ {
+		_, next, err := dnsName(msg, off)
+		if err != nil {
+			return err
+		}
+		off = next + 4
+	}
+	for i := 0; i < records; i++ {
+		name, next, err := dnsName(msg, off)
+		if err != nil {
+			return err
+		}
+		key := strings.ToLower(name)
+		if next+10 > len(msg) {
+			return errMalformedDNS
+		}
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		if start+length > len(msg) {
+			return errMalformedDNS
+		}
+		data := msg[start : start+length]
+		switch rtype {
+		case dnsTypePTR:
+			if strings.EqualFold(name, mdnsService) {
+				if instance, _, err := dnsName(msg, start); err == nil {
+					r.instances[strings.ToLower(instance)] = instance
+				}
+			}
+		case dnsTypeSRV:
+			if length >= 6 {
+				if target, _, err := dnsName(msg, start+6); err == nil {
+					r.srv[key] = mdnsSRV{target, int(binary.BigEndian.Uint16(data[4:]))}
+				}
+			}
+		case dnsTypeA, dnsTypeAAAA:
+			if length == net.IPv4len || length == net.IPv6len {
+				ip := net.IP(append([]byte(nil), data...))
+				if !containsIP(r.addrs[key], ip) {
+					r.addrs[key] = append(r.addrs[key], ip)
+				}
+			}
+		}
+		off = start + length
+	}
+	return nil
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, have := range ips {
+		if have.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// servers() returns the instances for which a SRV record was received.
+func (r *mdnsResults) servers() []DiscoveredServer {
+	var servers []DiscoveredServer
+	for key, instance := range r.instances {
+		srv, ok := r.srv[key]
+		if !ok {
+			continue
+		}
+		name := instance
+		if suffix := len(name) - len("."+mdnsService); suffix >= 0 && strings.EqualFold(name[suffix:], "."+mdnsService) {
+			name = name[:suffix]
+		}
+		servers = append(servers, DiscoveredServer{
+			Name:  name,
+			Host:  srv.target,
+			Port:  srv.port,
+			Addrs: r.addrs[strings.ToLower(srv.target)],
+		})
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name < servers[j].Name
+	})
+	return servers
+}
+
+// dnsName() reads a possibly compressed name starting at off, returning it
+// without the trailing dot along with the offset just past it.
+func dnsName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformedDNS
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 32 {
+				return "", 0, errMalformedDNS
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		case length&0xc0 != 0:
+			// The 0x40 and 0x80 label types are reserved.
+			return "", 0, errMalformedDNS
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errMalformedDNS
+			}
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
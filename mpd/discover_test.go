@@ -0,0 +1,165 @@
+package mpd
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dnsMessage builds a response with the given number of answers, which
+// are appended to it by the caller.
+func dnsMessage(answers int) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[6:], uint16(answers))
+	return msg
+}
+
+func appendName(msg []byte, name string) []byte {
+	for _, label := range strings.Split(name, ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+func appendRecord(msg []byte, name string, rtype uint16, data []byte) []byte {
+	msg = appendName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, rtype)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(data)))
+	return append(msg, data...)
+}
+
+func srvData(port uint16, target string) []byte {
+	data := make([]byte, 4, 64)
+	data = binary.BigEndian.AppendUint16(data, port)
+	return appendName(data, target)
+}
+
+// parseWithin() parses msg, failing the test if parsing takes too long.
+func parseWithin(t *testing.T, r *mdnsResults, msg []byte) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- r.parse(msg) }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("parse() didn't return")
+		return nil
+	}
+}
+
+func TestDiscoverParse(t *testing.T) {
+	msg := dnsMessage(4)
+	msg = appendRecord(msg, mdnsService, dnsTypePTR, appendName(nil, "Music Player @ musicbox._mpd._tcp.local"))
+	// Names are matched regardless of case.
+	msg = appendRecord(msg, "music player @ MUSICBOX._MPD._TCP.LOCAL", dnsTypeSRV, srvData(6600, "musicbox.local"))
+	msg = appendRecord(msg, "MusicBox.local", dnsTypeA, []byte{192, 168, 1, 20})
+	msg = appendRecord(msg, "musicbox.LOCAL", dnsTypeA, []byte{192, 168, 1, 20})
+
+	r := newMDNSResults()
+	if err := parseWithin(t, r, msg); err != nil {
+		t.Fatal(err)
+	}
+	want := []DiscoveredServer{{
+		Name:  "Music Player @ musicbox",
+		Host:  "musicbox.local",
+		Port:  6600,
+		Addrs: []net.IP{{192, 168, 1, 20}},
+	}}
+	if got := r.servers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("servers() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiscoverParseCompressed(t *testing.T) {
+	msg := dnsMessage(2)
+	service := len(msg)
+	msg = appendRecord(msg, mdnsService, dnsTypePTR, nil)
+	// Point the PTR data at "Kitchen" followed by the service name.
+	instance := len(msg)
+	msg = append(msg, 7)
+	msg = append(msg, "Kitchen"...)
+	msg = binary.BigEndian.AppendUint16(msg, 0xc000|uint16(service))
+	binary.BigEndian.PutUint16(msg[instance-2:], uint16(len(msg)-instance))
+	srv := binary.BigEndian.AppendUint16(nil, 0xc000|uint16(instance))
+	msg = append(msg, srv...)
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypeSRV)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	data := srvData(6601, "kitchen.local")
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(data)))
+	msg = append(msg, data...)
+
+	r := newMDNSResults()
+	if err := parseWithin(t, r, msg); err != nil {
+		t.Fatal(err)
+	}
+	got := r.servers()
+	if len(got) != 1 || got[0].Name != "Kitchen" || got[0].Host != "kitchen.local" || got[0].Port != 6601 {
+		t.Errorf("servers() = %+v", got)
+	}
+}
+
+func TestDiscoverParseMalformed(t *testing.T) {
+	valid := appendRecord(dnsMessage(1), "musicbox.local", dnsTypeA, []byte{10, 0, 0, 1})
+	selfLoop := dnsMessage(1)
+	selfLoop = binary.BigEndian.AppendUint16(selfLoop, 0xc000|12)
+	twoLoop := dnsMessage(1)
+	twoLoop = binary.BigEndian.AppendUint16(twoLoop, 0xc000|14)
+	twoLoop = binary.BigEndian.AppendUint16(twoLoop, 0xc000|12)
+	labelLoop := dnsMessage(1)
+	labelLoop = append(labelLoop, 1, 'a')
+	labelLoop = binary.BigEndian.AppendUint16(labelLoop, 0xc000|12)
+	lotsOfRecords := dnsMessage(0xffff)
+	binary.BigEndian.PutUint16(lotsOfRecords[4:], 0xffff)
+
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"empty", nil},
+		{"short header", make([]byte, 11)},
+		{"pointer to itself", selfLoop},
+		{"pointer loop", twoLoop},
+		{"loop through a label", labelLoop},
+		{"pointer past the end", binary.BigEndian.AppendUint16(dnsMessage(1), 0xc000|0x3fff)},
+		{"truncated pointer", append(dnsMessage(1), 0xc0)},
+		{"truncated label", append(dnsMessage(1), 10, 'a', 'b')},
+		{"reserved label type", append(dnsMessage(1), 0x40, 0)},
+		{"counts past the end", lotsOfRecords},
+	}
+	for i := 12; i < len(valid); i++ {
+		tests = append(tests, struct {
+			name string
+			msg  []byte
+		}{"truncated at " + strconv.Itoa(i), valid[:i]})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newMDNSResults()
+			if err := parseWithin(t, r, tt.msg); err != errMalformedDNS {
+				t.Errorf("parse() = %v, want %v", err, errMalformedDNS)
+			}
+		})
+	}
+}
+
+func TestDiscoverParseRecordPastEnd(t *testing.T) {
+	msg := appendRecord(dnsMessage(1), "musicbox.local", dnsTypeA, []byte{10, 0, 0, 1})
+	// Claim more data than there is.
+	binary.BigEndian.PutUint16(msg[len(msg)-6:], 100)
+	r := newMDNSResults()
+	if err := parseWithin(t, r, msg); err != errMalformedDNS {
+		t.Errorf("parse() = %v, want %v", err, errMalformedDNS)
+	}
+	if len(r.addrs) != 0 {
+		t.Errorf("recorded addresses %v", r.addrs)
+	}
+}
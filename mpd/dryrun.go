@@ -0,0 +1,136 @@
+package mpd
+
+import (
+	"errors"
+	"strings"
+)
+
+// SetDryRun() puts the connection into dry-run mode, in which commands
+// that would change the server's state are passed to record instead of
+// being sent, while all other commands are sent as usual. Skipped
+// commands behave as though they succeeded with an empty response. In
+// command lists, only the commands that change state are skipped.
+// Calling it with a nil record function turns dry-run mode off.
+//
+// This makes it possible to preview what a script would do to a live
+// server, for example:
+//
+//	conn.SetDryRun(func(cmd string) { log.Println("would send:", cmd) })
+func (conn *Conn) SetDryRun(record func(cmd string)) {
+	conn.acquire()
+	defer conn.lock.Unlock()
+	conn.dryRun = record
+}
+
+// dryRunSkips records which commands of a command list were skipped in
+// dry-run mode, so that its reply can be made to look as though they had
+// been sent.
+type dryRunSkips struct {
+	okList  bool   // whether the list was started with command_list_ok_begin
+	skipped []bool // for each command in the list
+}
+
+// filterDryRun() removes the commands that change state from cmd, which
+// may be a command list, recording each one. It returns the empty string
+// if nothing is left to send, and what was skipped from a command list,
+// for fill(). It must be called with the lock held.
+func (conn *Conn) filterDryRun(cmd string) (string, *dryRunSkips) {
+	if conn.dryRun == nil {
+		return cmd, nil
+	}
+	var kept []string
+	var skips dryRunSkips
+	anySkipped, empty := false, true
+	for _, line := range strings.Split(cmd, "\n") {
+		args := splitArgs(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "command_list_ok_begin":
+			skips.okList = true
+			fallthrough
+		case "command_list_begin", "command_list_end":
+			kept = append(kept, line)
+			continue
+		}
+		skipped := isMutating(args[0], args[1:])
+		skips.skipped = append(skips.skipped, skipped)
+		if skipped {
+			conn.dryRun(line)
+			anySkipped = true
+			continue
+		}
+		kept = append(kept, line)
+		empty = false
+	}
+	var result *dryRunSkips
+	if anySkipped && len(skips.skipped) > 1 {
+		result = &skips
+	}
+	if empty {
+		return "", result
+	}
+	return strings.Join(kept, "\n"), result
+}
+
+// fill() makes the reply to a command list that had commands skipped look
+// like the reply to the whole list: a failed command's index counts the
+// skipped commands, and with command_list_ok_begin, each skipped command
+// that would have run gets an empty response of its own.
+func (skips *dryRunSkips) fill(r reply) reply {
+	if skips == nil {
+		return r
+	}
+	var ackErr *AckError
+	failed := -1 // the index of the failed command among those sent
+	if errors.As(r.err, &ackErr) {
+		failed = ackErr.Index
+	}
+	var lines []string
+	rest := r.lines
+	sent := 0
+	for i, skipped := range skips.skipped {
+		if skipped {
+			if failed >= 0 && sent > failed {
+				// The list stopped at the failed command.
+				break
+			}
+			if skips.okList {
+				lines = append(lines, "list_OK")
+			}
+			continue
+		}
+		if sent == failed {
+			ackErr.Index = i
+			lines = append(lines, rest...)
+			rest = nil
+			break
+		}
+		if skips.okList {
+			n := nextListOK(rest) + 1
+			lines = append(lines, rest[:n]...)
+			rest = rest[n:]
+		}
+		sent++
+	}
+	if !skips.okList {
+		lines = r.lines
+	}
+	return reply{lines: lines, err: r.err}
+}
+
+// nextListOK() returns the index of the first list_OK line in lines, or
+// the index of the last line if there is none.
+func nextListOK(lines []string) int {
+	for i := 0; i < len(lines); i++ {
+		if lines[i] == "list_OK" {
+			return i
+		}
+		if strings.HasPrefix(lines[i], "binary: ") {
+			// Skip the data, which might well read "list_OK".
+			i++
+		}
+	}
+	return len(lines) - 1
+}
@@ -0,0 +1,55 @@
+package mpd_test
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	srv := startServer(t)
+	srv.Handle("status", respond("state: play"))
+	srv.Handle("currentsong", respond("file: a.flac"))
+	srv.Handle("clear", respond())
+	conn := connect(t, srv)
+	var recorded []string
+	conn.SetDryRun(func(cmd string) { recorded = append(recorded, cmd) })
+
+	if _, err := conn.Send("clear"); err != nil {
+		t.Errorf("Send(clear): %v", err)
+	}
+	resp, err := conn.Send("status")
+	if err != nil || resp.Get("state") != "play" {
+		t.Errorf("Send(status) = %v, %v", resp, err)
+	}
+
+	// Only the commands that change state are skipped from a list, and
+	// each of them still gets a response.
+	resps, failed, err := conn.SendListOK([]string{"status", "clear", "currentsong"})
+	if err != nil || failed != -1 {
+		t.Fatalf("SendListOK() failed at %d: %v", failed, err)
+	}
+	if len(resps) != 3 || resps[0].Get("state") != "play" || len(resps[1]) != 0 || resps[2].Get("file") != "a.flac" {
+		t.Errorf("SendListOK() = %v", resps)
+	}
+
+	// A failed command is reported at its index in the whole list.
+	_, failed, err = conn.SendListOK([]string{"clear", "bogus", "clear"})
+	if err == nil || failed != 1 {
+		t.Errorf("SendListOK() failed at %d with %v, want 1", failed, err)
+	}
+
+	if want := []string{"clear", "clear", "clear", "clear"}; !slices.Equal(recorded, want) {
+		t.Errorf("recorded %q, want %q", recorded, want)
+	}
+	if got, want := srv.Received(), []string{"status", "status", "currentsong", "bogus"}; !slices.Equal(got, want) {
+		t.Errorf("server received %q, want %q", got, want)
+	}
+
+	conn.SetDryRun(nil)
+	if _, err := conn.Send("clear"); err != nil {
+		t.Errorf("Send(clear): %v", err)
+	}
+	if got := srv.Received(); got[len(got)-1] != "clear" {
+		t.Errorf("clear wasn't sent after turning dry-run off")
+	}
+}
@@ -0,0 +1,185 @@
+package mpd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DuplicateOptions configures FindDuplicates().
+type DuplicateOptions struct {
+	// Tags lists the tags whose normalized values must all match for two
+	// songs to be considered duplicates. Case, punctuation and extra
+	// whitespace are ignored. Leave empty to compare fingerprints only.
+	Tags []string
+
+	// ExactFingerprint enables comparing chromaprint fingerprints, which
+	// must be identical for songs to match. That finds copies of the
+	// same audio, but not the same recording encoded differently, whose
+	// fingerprints differ slightly. If Tags is also set, only songs that
+	// already match on tags are fingerprinted, and each group is narrowed
+	// down to songs that match on both.
+	ExactFingerprint bool
+
+	// Conns are the connections used to compute fingerprints, one worker
+	// per connection. If empty, the connection FindDuplicates() was
+	// called on is used on its own.
+	Conns []*Conn
+}
+
+// DuplicateGroup is a set of songs that are likely duplicates of one
+// another.
+type DuplicateGroup struct {
+	Key   string // the normalized tags or fingerprint the songs share
+	Songs []Song
+}
+
+// Fingerprint() computes the chromaprint fingerprint of a song.
+func (conn *Conn) Fingerprint(uri string) (string, error) {
+	attrs, err := conn.attrs("getfingerprint " + quote(uri))
+	if err != nil {
+		return "", err
+	}
+	return attrs["chromaprint"], nil
+}
+
+// FindDuplicates() scans the whole database for songs that are likely
+// duplicates, returning groups of at least two songs each. Songs that
+// the server fails to fingerprint are left out of the results.
+func (conn *Conn) FindDuplicates(opts DuplicateOptions) ([]DuplicateGroup, error) {
+	if len(opts.Tags) == 0 && !opts.ExactFingerprint {
+		return nil, errors.New("no tags or fingerprints to compare songs by")
+	}
+	songs, err := conn.ListAllInfo()
+	if err != nil {
+		return nil, err
+	}
+	groups := []DuplicateGroup{{Songs: songs}}
+	if len(opts.Tags) > 0 {
+		groups = groupSongs(songs, func(song *Song) string {
+			return tagKey(song, opts.Tags)
+		})
+	}
+	if !opts.ExactFingerprint {
+		return groups, nil
+	}
+
+	conns := opts.Conns
+	if len(conns) == 0 {
+		conns = []*Conn{conn}
+	}
+	var uris []string
+	for _, group := range groups {
+		for _, song := range group.Songs {
+			uris = append(uris, song.File)
+		}
+	}
+	prints, err := fingerprintAll(conns, uris)
+	if err != nil {
+		return nil, err
+	}
+	var result []DuplicateGroup
+	for _, group := range groups {
+		for _, sub := range groupSongs(group.Songs, func(song *Song) string { return prints[song.File] }) {
+			if len(opts.Tags) > 0 {
+				sub.Key = group.Key
+			}
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
+// groupSongs() groups songs by key, keeping only groups of two or more
+// and skipping songs with an empty key.
+func groupSongs(songs []Song, key func(*Song) string) []DuplicateGroup {
+	byKey := make(map[string][]Song)
+	var keys []string
+	for i := range songs {
+		k := key(&songs[i])
+		if k == "" {
+			continue
+		}
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], songs[i])
+	}
+	sort.Strings(keys)
+	var groups []DuplicateGroup
+	for _, k := range keys {
+		if len(byKey[k]) > 1 {
+			groups = append(groups, DuplicateGroup{Key: k, Songs: byKey[k]})
+		}
+	}
+	return groups
+}
+
+// tagKey() joins the normalized values of the given tags, or returns the
+// empty string if any of them is missing.
+func tagKey(song *Song, tags []string) string {
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = normalizeTag(strings.Join(song.Tags[tag], " "))
+		if parts[i] == "" {
+			return ""
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// normalizeTag() lowercases a tag value, drops punctuation and collapses
+// runs of whitespace.
+func normalizeTag(value string) string {
+	fields := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// fingerprintAll() fingerprints the given songs using one worker per
+// connection. Songs the server can't fingerprint are omitted from the
+// result; any other error aborts the whole run.
+func fingerprintAll(conns []*Conn, uris []string) (map[string]string, error) {
+	jobs := make(chan string)
+	done := make(chan struct{})
+	var (
+		mu       sync.Mutex
+		prints   = make(map[string]string, len(uris))
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for _, c := range conns {
+		wg.Add(1)
+		go func(c *Conn) {
+			defer wg.Done()
+			for uri := range jobs {
+				fp, err := c.Fingerprint(uri)
+				mu.Lock()
+				if err == nil {
+					prints[uri] = fp
+				} else if _, ok := AckCode(err); !ok && firstErr == nil {
+					firstErr = err
+					close(done)
+				}
+				mu.Unlock()
+			}
+		}(c)
+	}
+feed:
+	for _, uri := range uris {
+		select {
+		case jobs <- uri:
+		case <-done:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return prints, nil
+}
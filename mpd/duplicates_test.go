@@ -0,0 +1,125 @@
+package mpd_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+// library is the database of the fake server for the duplicate tests,
+// with the fingerprint of each song, if it has one.
+var library = []struct {
+	file, artist, title, print string
+}{
+	{"a/one.flac", "The Band", "One", "AQAAA"},
+	{"b/one.mp3", "the band", "One!", "AQAAA"},
+	{"c/one-live.flac", "The Band", "One", "AQAAB"},
+	{"d/two.flac", "The Band", "Two", "AQAAC"},
+	{"e/two-copy.flac", "Other Band", "Two", "AQAAC"},
+	{"f/broken.flac", "The Band", "Two", ""},
+}
+
+// startLibrary() starts a fake server with the library in its database.
+func startLibrary(t *testing.T) *testutil.Server {
+	t.Helper()
+	srv := startServer(t)
+	var lines []string
+	prints := make(map[string]string)
+	for _, song := range library {
+		lines = append(lines, "file: "+song.file, "Artist: "+song.artist, "Title: "+song.title)
+		prints[song.file] = song.print
+	}
+	srv.Handle("listallinfo", respond(lines...))
+	srv.Handle("getfingerprint", func(args []string) ([]string, error) {
+		if prints[args[0]] == "" {
+			return nil, &testutil.Ack{Code: 50, Message: "failed to decode"}
+		}
+		return []string{"chromaprint: " + prints[args[0]]}, nil
+	})
+	return srv
+}
+
+// groupFiles() returns the files of each group.
+func groupFiles(groups []mpd.DuplicateGroup) [][]string {
+	var files [][]string
+	for _, group := range groups {
+		var names []string
+		for _, song := range group.Songs {
+			names = append(names, song.File)
+		}
+		files = append(files, names)
+	}
+	return files
+}
+
+func TestFindDuplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts mpd.DuplicateOptions
+		want [][]string
+	}{
+		{
+			"tags",
+			mpd.DuplicateOptions{Tags: []string{"Artist", "Title"}},
+			[][]string{
+				{"a/one.flac", "b/one.mp3", "c/one-live.flac"},
+				{"d/two.flac", "f/broken.flac"},
+			},
+		},
+		{
+			"fingerprints",
+			mpd.DuplicateOptions{ExactFingerprint: true},
+			[][]string{
+				{"a/one.flac", "b/one.mp3"},
+				{"d/two.flac", "e/two-copy.flac"},
+			},
+		},
+		{
+			"tags and fingerprints",
+			mpd.DuplicateOptions{Tags: []string{"Artist", "Title"}, ExactFingerprint: true},
+			[][]string{
+				{"a/one.flac", "b/one.mp3"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := startLibrary(t)
+			conn := connect(t, srv)
+			groups, err := conn.FindDuplicates(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := groupFiles(groups)
+			if !slices.EqualFunc(got, test.want, slices.Equal) {
+				t.Errorf("FindDuplicates() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesConns(t *testing.T) {
+	srv := startLibrary(t)
+	conn := connect(t, srv)
+	opts := mpd.DuplicateOptions{
+		ExactFingerprint: true,
+		Conns:            []*mpd.Conn{connect(t, srv), connect(t, srv), connect(t, srv)},
+	}
+	groups, err := conn.FindDuplicates(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Errorf("FindDuplicates() = %q, want 2 groups", groupFiles(groups))
+	}
+}
+
+func TestFindDuplicatesNothingToCompare(t *testing.T) {
+	srv := startLibrary(t)
+	conn := connect(t, srv)
+	if _, err := conn.FindDuplicates(mpd.DuplicateOptions{}); err == nil {
+		t.Error("FindDuplicates() without tags or fingerprints succeeded")
+	}
+}
@@ -0,0 +1,203 @@
+package mpd
+
+import (
+	"fmt"
+)
+
+var replayGainModeNames = map[ReplayGainMode]string{
+	ReplayGainOff:   "off",
+	ReplayGainTrack: "track",
+	ReplayGainAlbum: "album",
+	ReplayGainAuto:  "auto",
+}
+
+// String() returns the mode as MPD names it, e.g. "track".
+func (mode ReplayGainMode) String() string {
+	if name, ok := replayGainModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("ReplayGainMode(%d)", int(mode))
+}
+
+// ParseReplayGainMode() parses a mode as MPD names it.
+func ParseReplayGainMode(s string) (ReplayGainMode, error) {
+	for mode, name := range replayGainModeNames {
+		if s == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown replay gain mode '%s'", s)
+}
+
+func (mode ReplayGainMode) MarshalText() ([]byte, error) {
+	if _, ok := replayGainModeNames[mode]; !ok {
+		return nil, fmt.Errorf("unknown replay gain mode '%d'", mode)
+	}
+	return []byte(mode.String()), nil
+}
+
+func (mode *ReplayGainMode) UnmarshalText(text []byte) error {
+	m, err := ParseReplayGainMode(string(text))
+	if err != nil {
+		return err
+	}
+	*mode = m
+	return nil
+}
+
+// SingleMode is the state of the single option.
+type SingleMode int
+
+const (
+	SingleOff     SingleMode = iota
+	SingleOn                 // stop or repeat after the current song
+	SingleOneshot            // like SingleOn, but turns itself off afterwards
+)
+
+// String() returns "off", "on" or "oneshot".
+func (mode SingleMode) String() string {
+	return toggleModeString("SingleMode", int(mode))
+}
+
+// ParseSingleMode() parses a mode either as String() returns it or as MPD
+// reports it in status ("0", "1" or "oneshot").
+func ParseSingleMode(s string) (SingleMode, error) {
+	mode, err := parseToggleMode("single", s)
+	return SingleMode(mode), err
+}
+
+func (mode SingleMode) MarshalText() ([]byte, error) {
+	return marshalToggleMode("single", int(mode))
+}
+
+func (mode *SingleMode) UnmarshalText(text []byte) error {
+	m, err := ParseSingleMode(string(text))
+	if err != nil {
+		return err
+	}
+	*mode = m
+	return nil
+}
+
+// ConsumeMode is the state of the consume option.
+type ConsumeMode int
+
+const (
+	ConsumeOff     ConsumeMode = iota
+	ConsumeOn                  // remove each song from the queue after playing it
+	ConsumeOneshot             // like ConsumeOn, but turns itself off afterwards
+)
+
+// String() returns "off", "on" or "oneshot".
+func (mode ConsumeMode) String() string {
+	return toggleModeString("ConsumeMode", int(mode))
+}
+
+// ParseConsumeMode() parses a mode either as String() returns it or as
+// MPD reports it in status ("0", "1" or "oneshot").
+func ParseConsumeMode(s string) (ConsumeMode, error) {
+	mode, err := parseToggleMode("consume", s)
+	return ConsumeMode(mode), err
+}
+
+func (mode ConsumeMode) MarshalText() ([]byte, error) {
+	return marshalToggleMode("consume", int(mode))
+}
+
+func (mode *ConsumeMode) UnmarshalText(text []byte) error {
+	m, err := ParseConsumeMode(string(text))
+	if err != nil {
+		return err
+	}
+	*mode = m
+	return nil
+}
+
+// The single and consume modes share their representation.
+
+var toggleModeNames = []string{"off", "on", "oneshot"}
+
+func toggleModeString(typeName string, mode int) string {
+	if mode >= 0 && mode < len(toggleModeNames) {
+		return toggleModeNames[mode]
+	}
+	return fmt.Sprintf("%s(%d)", typeName, mode)
+}
+
+func parseToggleMode(option, s string) (int, error) {
+	switch s {
+	case "0", "off":
+		return 0, nil
+	case "1", "on":
+		return 1, nil
+	case "oneshot":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unknown %s mode '%s'", option, s)
+}
+
+// toggleModeArg() returns a mode as the command setting it takes it.
+func toggleModeArg(option string, mode int) (string, error) {
+	switch mode {
+	case 0:
+		return "0", nil
+	case 1:
+		return "1", nil
+	case 2:
+		return "oneshot", nil
+	}
+	return "", fmt.Errorf("unknown %s mode '%d'", option, mode)
+}
+
+func marshalToggleMode(option string, mode int) ([]byte, error) {
+	if mode < 0 || mode >= len(toggleModeNames) {
+		return nil, fmt.Errorf("unknown %s mode '%d'", option, mode)
+	}
+	return []byte(toggleModeNames[mode]), nil
+}
+
+// PlayerState is the playback state of the player.
+type PlayerState int
+
+const (
+	Stopped PlayerState = iota
+	Playing
+	Paused
+)
+
+var playerStateNames = []string{"stop", "play", "pause"}
+
+// String() returns the state as MPD reports it: "stop", "play" or
+// "pause".
+func (state PlayerState) String() string {
+	if state >= 0 && int(state) < len(playerStateNames) {
+		return playerStateNames[state]
+	}
+	return fmt.Sprintf("PlayerState(%d)", int(state))
+}
+
+// ParsePlayerState() parses a state as MPD reports it.
+func ParsePlayerState(s string) (PlayerState, error) {
+	for i, name := range playerStateNames {
+		if s == name {
+			return PlayerState(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown player state '%s'", s)
+}
+
+func (state PlayerState) MarshalText() ([]byte, error) {
+	if state < 0 || int(state) >= len(playerStateNames) {
+		return nil, fmt.Errorf("unknown player state '%d'", state)
+	}
+	return []byte(state.String()), nil
+}
+
+func (state *PlayerState) UnmarshalText(text []byte) error {
+	s, err := ParsePlayerState(string(text))
+	if err != nil {
+		return err
+	}
+	*state = s
+	return nil
+}
@@ -0,0 +1,57 @@
+package mpd
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// DefaultHost and DefaultPort are used by ConnectDefault() when the
+// environment doesn't say otherwise.
+const (
+	DefaultHost = "localhost"
+	DefaultPort = "6600"
+)
+
+// ConnectDefault() connects to the server named by the environment, the
+// same way mpc does. MPD_HOST gives the host name, or a Unix socket path
+// if it starts with "/", or an abstract socket name if it starts with
+// "@". It may be prefixed with "password@" to authenticate as well.
+// MPD_PORT gives the TCP port. Unset variables default to DefaultHost and
+// DefaultPort. Any options are applied after those derived from the
+// environment.
+func ConnectDefault(opts ...Option) (*Conn, error) {
+	network, addr, password := resolveEnv(os.Getenv("MPD_HOST"), os.Getenv("MPD_PORT"))
+	envOpts := []Option{WithNetwork(network)}
+	if password != "" {
+		envOpts = append(envOpts, WithPassword(password))
+	}
+	return ConnectWithOptions(addr, append(envOpts, opts...)...)
+}
+
+// resolveEnv() works out the address to connect to from the values of
+// MPD_HOST and MPD_PORT.
+func resolveEnv(host, port string) (network, addr, password string) {
+	// A socket path or an abstract socket name, marked by an '@' at the
+	// very start, has no password, and may contain '@' itself. Otherwise
+	// the password ends at the last '@', since it may contain '@' too,
+	// unless that '@' starts an abstract socket name.
+	if !strings.HasPrefix(host, "/") && !strings.HasPrefix(host, "@") {
+		if i := strings.LastIndexByte(host, '@'); i > 0 {
+			if host[i-1] == '@' {
+				i--
+			}
+			password, host = host[:i], host[i+1:]
+		}
+	}
+	if host == "" {
+		host = DefaultHost
+	}
+	if strings.HasPrefix(host, "/") || strings.HasPrefix(host, "@") {
+		return "unix", host, password
+	}
+	if port == "" {
+		port = DefaultPort
+	}
+	return "tcp", net.JoinHostPort(host, port), password
+}
@@ -0,0 +1,36 @@
+package mpd_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestResolveEnv(t *testing.T) {
+	tests := []struct {
+		host, port              string
+		network, addr, password string
+	}{
+		{"", "", "tcp", "localhost:6600", ""},
+		{"", "6601", "tcp", "localhost:6601", ""},
+		{"music.local", "", "tcp", "music.local:6600", ""},
+		{"music.local", "7000", "tcp", "music.local:7000", ""},
+		{"::1", "", "tcp", "[::1]:6600", ""},
+		{"secret@music.local", "", "tcp", "music.local:6600", "secret"},
+		{"p@ss@music.local", "", "tcp", "music.local:6600", "p@ss"},
+		{"secret@", "", "tcp", "localhost:6600", "secret"},
+		{"/run/mpd/socket", "", "unix", "/run/mpd/socket", ""},
+		{"/run/user@1000/mpd/socket", "", "unix", "/run/user@1000/mpd/socket", ""},
+		{"secret@/run/mpd/socket", "", "unix", "/run/mpd/socket", "secret"},
+		{"@mpd", "", "unix", "@mpd", ""},
+		{"@mpd@home", "", "unix", "@mpd@home", ""},
+		{"secret@@mpd", "", "unix", "@mpd", "secret"},
+	}
+	for _, test := range tests {
+		network, addr, password := mpd.ResolveEnv(test.host, test.port)
+		if network != test.network || addr != test.addr || password != test.password {
+			t.Errorf("ResolveEnv(%q, %q) = %q, %q, %q, want %q, %q, %q",
+				test.host, test.port, network, addr, password, test.network, test.addr, test.password)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package mpd
+
+var SplitArgs = splitArgs
+
+var ReorderCommands = reorderCommands
+
+var ResolveEnv = resolveEnv
@@ -0,0 +1,55 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ConnectAny() connects to the first of several addresses that responds,
+// for setups with a backup MPD instance or where the socket path differs
+// between machines. The addresses are tried in order, and again in order
+// whenever the connection is re-established with WithReconnect(), so the
+// first one is used again once it is back. The error reports why each of
+// them failed.
+//
+// Use PreferUnix() to try Unix sockets first.
+func ConnectAny(ctx context.Context, addrs []string, opts ...Option) (*Conn, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses to connect to")
+	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &session{addrs: slices.Clone(addrs), opts: o}
+	if err := s.connect(ctx); err != nil {
+		return nil, err
+	}
+	return s.newConn().connected(ctx)
+}
+
+// PreferUnix() returns the addresses with Unix socket paths and abstract
+// socket names moved ahead of the rest, which otherwise keep their order.
+func PreferUnix(addrs []string) []string {
+	sorted := slices.Clone(addrs)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return unixRank(a) - unixRank(b)
+	})
+	return sorted
+}
+
+func unixRank(addr string) int {
+	if networkFor(addr) == "unix" {
+		return 0
+	}
+	return 1
+}
+
+// Addr() returns the address the connection was made to, which for
+// ConnectAny() is the one that responded.
+func (conn *Conn) Addr() string {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	return conn.addr
+}
@@ -0,0 +1,102 @@
+package mpd
+
+import (
+	"time"
+)
+
+// FingerprintResult reports the outcome of fingerprinting one song.
+type FingerprintResult struct {
+	URI         string
+	Fingerprint string
+	Err         error // set if the server failed to fingerprint the song
+	Done, Total int   // progress through the job, including skipped songs
+}
+
+// FingerprintJob computes the fingerprints of many songs, storing each
+// one in a sticker as it goes. Songs that already have the sticker are
+// skipped, so an interrupted job picks up where it left off when run
+// again.
+type FingerprintJob struct {
+	Conn *Conn
+	URIs []string
+
+	// Sticker is the name of the sticker fingerprints are stored in.
+	// It defaults to "chromaprint".
+	Sticker string
+
+	// Interval is the minimum time between fingerprint requests, which
+	// leaves the server free to handle other clients. Zero means no
+	// limit.
+	Interval time.Duration
+
+	// Clock is used to enforce Interval. It defaults to SystemClock.
+	Clock Clock
+
+	// Progress, if non-nil, is called after each song is processed.
+	Progress func(FingerprintResult)
+}
+
+// Run() fingerprints every song that doesn't have one yet, returning all
+// of the fingerprints known for the job's songs. It stops early, without
+// error, when stop is closed. Songs that can't be fingerprinted are
+// reported through Progress and otherwise ignored.
+func (job *FingerprintJob) Run(stop <-chan struct{}) (map[string]string, error) {
+	sticker := job.Sticker
+	if sticker == "" {
+		sticker = "chromaprint"
+	}
+	progress := job.Progress
+	if progress == nil {
+		progress = func(FingerprintResult) {}
+	}
+
+	stored, err := job.Conn.stickerFind("", sticker)
+	if err != nil {
+		return nil, err
+	}
+	prints := make(map[string]string, len(job.URIs))
+	var pending []string
+	for _, uri := range job.URIs {
+		if fp, ok := stored[uri]; ok {
+			prints[uri] = fp
+		} else {
+			pending = append(pending, uri)
+		}
+	}
+
+	var tick <-chan time.Time
+	if job.Interval > 0 {
+		ticker := clockOrSystem(job.Clock).NewTicker(job.Interval)
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+	done := len(prints)
+	for i, uri := range pending {
+		if i > 0 && tick != nil {
+			select {
+			case <-stop:
+				return prints, nil
+			case <-tick:
+			}
+		}
+		select {
+		case <-stop:
+			return prints, nil
+		default:
+		}
+
+		done++
+		result := FingerprintResult{URI: uri, Done: done, Total: len(job.URIs)}
+		result.Fingerprint, result.Err = job.Conn.Fingerprint(uri)
+		if result.Err == nil {
+			prints[uri] = result.Fingerprint
+			if err := job.Conn.stickerSet(uri, sticker, result.Fingerprint); err != nil {
+				return prints, err
+			}
+		} else if _, ok := AckCode(result.Err); !ok {
+			return prints, result.Err
+		}
+		progress(result)
+	}
+	return prints, nil
+}
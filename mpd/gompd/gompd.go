@@ -0,0 +1,322 @@
+// Package gompd provides an API modeled on that of the fhs/gompd package,
+// implemented on top of the mpd package, so that applications written
+// against gompd can switch to this one without a rewrite. Responses are
+// returned as untyped Attrs, just as gompd returns them; new code should
+// use the mpd package directly.
+package gompd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// Attrs is a set of attributes returned by MPD.
+type Attrs map[string]string
+
+// Client is a connection to an MPD server.
+type Client struct {
+	conn *mpd.Conn
+}
+
+// Dial() connects to an MPD server over the given network, such as "tcp"
+// or "unix".
+func Dial(network, addr string) (*Client, error) {
+	return DialAuthenticated(network, addr, "")
+}
+
+// DialAuthenticated() is like Dial(), but sends the given password right
+// after connecting if it isn't empty.
+func DialAuthenticated(network, addr, password string) (*Client, error) {
+	conn, err := mpd.DialNetwork(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn}
+	if password != "" {
+		if err := conn.Password(password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Conn() returns the underlying connection, for using features of the
+// mpd package that gompd lacks.
+func (c *Client) Conn() *mpd.Conn {
+	return c.conn
+}
+
+// Close() closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Ping() sends a no-op command to keep the connection alive.
+func (c *Client) Ping() error {
+	return c.conn.Ping()
+}
+
+// Command is a command that has been formatted but not yet sent. It is
+// created with Client.Command().
+type Command struct {
+	client *Client
+	cmd    string
+	err    error // from quoting the arguments
+}
+
+// Command() formats a command, quoting each argument. Arguments are
+// substituted for %s, %d and the like as with fmt.Sprintf, except that
+// string arguments are quoted. A string argument with a line break in it
+// makes the command fail with mpd.ErrLineBreak when sent.
+func (c *Client) Command(format string, args ...interface{}) *Command {
+	quoted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			q, err := mpd.Quote(s)
+			if err != nil {
+				return &Command{client: c, err: err}
+			}
+			quoted[i] = q
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return &Command{client: c, cmd: fmt.Sprintf(format, quoted...)}
+}
+
+// send() sends the command.
+func (cmd *Command) send() (mpd.Response, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	return cmd.client.conn.Send(cmd.cmd)
+}
+
+// OK() sends the command, discarding the response.
+func (cmd *Command) OK() error {
+	_, err := cmd.send()
+	return err
+}
+
+// Attrs() sends the command and returns the response as a single set of
+// attributes.
+func (cmd *Command) Attrs() (Attrs, error) {
+	resp, err := cmd.send()
+	if err != nil {
+		return nil, err
+	}
+	return Attrs(resp.Map()), nil
+}
+
+// AttrsList() sends the command and splits the response into a set of
+// attributes for each line whose key is startKey.
+func (cmd *Command) AttrsList(startKey string) ([]Attrs, error) {
+	resp, err := cmd.send()
+	if err != nil {
+		return nil, err
+	}
+	return attrsList(resp, startKey), nil
+}
+
+func (c *Client) Status() (Attrs, error) {
+	return c.Command("status").Attrs()
+}
+
+func (c *Client) Stats() (Attrs, error) {
+	return c.Command("stats").Attrs()
+}
+
+func (c *Client) CurrentSong() (Attrs, error) {
+	return c.Command("currentsong").Attrs()
+}
+
+// PlaylistInfo() returns the songs in the queue from position start up
+// to, but not including, end. If both are negative, every song is
+// returned; if only end is negative, just the song at start is.
+func (c *Client) PlaylistInfo(start, end int) ([]Attrs, error) {
+	var cmd *Command
+	switch {
+	case start < 0 && end < 0:
+		cmd = c.Command("playlistinfo")
+	case end < 0:
+		cmd = c.Command("playlistinfo %d", start)
+	case start < 0:
+		return nil, errors.New("negative start index")
+	default:
+		cmd = c.Command("playlistinfo %d:%d", start, end)
+	}
+	return cmd.AttrsList("file")
+}
+
+// ListAllInfo() returns every song under the given directory.
+func (c *Client) ListAllInfo(uri string) ([]Attrs, error) {
+	resp, err := c.conn.Send(c.Command("listallinfo %s", uri).cmd)
+	if err != nil {
+		return nil, err
+	}
+	var songs []Attrs
+	for _, attrs := range attrsList(resp, "file", "directory", "playlist") {
+		if _, ok := attrs["file"]; ok {
+			songs = append(songs, attrs)
+		}
+	}
+	return songs, nil
+}
+
+func (c *Client) ListPlaylists() ([]Attrs, error) {
+	return c.Command("listplaylists").AttrsList("playlist")
+}
+
+func (c *Client) PlaylistContents(name string) ([]Attrs, error) {
+	return c.Command("listplaylistinfo %s", name).AttrsList("file")
+}
+
+func (c *Client) PlaylistLoad(name string, start, end int) error {
+	if start < 0 || end < 0 {
+		return c.Command("load %s", name).OK()
+	}
+	return c.Command("load %s %d:%d", name, start, end).OK()
+}
+
+func (c *Client) PlaylistSave(name string) error {
+	return c.Command("save %s", name).OK()
+}
+
+func (c *Client) PlaylistRemove(name string) error {
+	return c.Command("rm %s", name).OK()
+}
+
+func (c *Client) Play(pos int) error {
+	if pos < 0 {
+		return c.Command("play").OK()
+	}
+	return c.Command("play %d", pos).OK()
+}
+
+func (c *Client) PlayID(id int) error {
+	if id < 0 {
+		return c.Command("playid").OK()
+	}
+	return c.Command("playid %d", id).OK()
+}
+
+func (c *Client) Pause(pause bool) error {
+	return c.Command("pause %s", binaryBool(pause)).OK()
+}
+
+func (c *Client) Stop() error {
+	return c.Command("stop").OK()
+}
+
+func (c *Client) Next() error {
+	return c.Command("next").OK()
+}
+
+func (c *Client) Previous() error {
+	return c.Command("previous").OK()
+}
+
+func (c *Client) Seek(pos, seconds int) error {
+	return c.Command("seek %d %d", pos, seconds).OK()
+}
+
+func (c *Client) SeekID(id, seconds int) error {
+	return c.Command("seekid %d %d", id, seconds).OK()
+}
+
+func (c *Client) SetVolume(volume int) error {
+	return c.Command("setvol %d", volume).OK()
+}
+
+func (c *Client) Random(random bool) error {
+	return c.Command("random %s", binaryBool(random)).OK()
+}
+
+func (c *Client) Repeat(repeat bool) error {
+	return c.Command("repeat %s", binaryBool(repeat)).OK()
+}
+
+func (c *Client) Single(single bool) error {
+	return c.Command("single %s", binaryBool(single)).OK()
+}
+
+func (c *Client) Consume(consume bool) error {
+	return c.Command("consume %s", binaryBool(consume)).OK()
+}
+
+func (c *Client) Add(uri string) error {
+	return c.Command("add %s", uri).OK()
+}
+
+// AddID() adds a song at the given position, or at the end of the queue
+// if pos is negative, and returns its id.
+func (c *Client) AddID(uri string, pos int) (int, error) {
+	cmd := c.Command("addid %s", uri)
+	if pos >= 0 {
+		cmd = c.Command("addid %s %d", uri, pos)
+	}
+	attrs, err := cmd.Attrs()
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(attrs["Id"])
+}
+
+func (c *Client) Delete(start, end int) error {
+	if end < 0 {
+		return c.Command("delete %d", start).OK()
+	}
+	return c.Command("delete %d:%d", start, end).OK()
+}
+
+func (c *Client) DeleteID(id int) error {
+	return c.Command("deleteid %d", id).OK()
+}
+
+func (c *Client) Move(start, end, pos int) error {
+	if end < 0 {
+		return c.Command("move %d %d", start, pos).OK()
+	}
+	return c.Command("move %d:%d %d", start, end, pos).OK()
+}
+
+func (c *Client) MoveID(id, pos int) error {
+	return c.Command("moveid %d %d", id, pos).OK()
+}
+
+func (c *Client) Shuffle(start, end int) error {
+	if start < 0 || end < 0 {
+		return c.Command("shuffle").OK()
+	}
+	return c.Command("shuffle %d:%d", start, end).OK()
+}
+
+func (c *Client) Clear() error {
+	return c.Command("clear").OK()
+}
+
+// Update() starts a database update and returns the job id.
+func (c *Client) Update(uri string) (int, error) {
+	return c.conn.Update(uri)
+}
+
+// attrsList() splits a response into a set of attributes for each line
+// whose key is one of startKeys.
+func attrsList(resp mpd.Response, startKeys ...string) []Attrs {
+	var result []Attrs
+	for _, record := range resp.Records(startKeys...) {
+		result = append(result, Attrs(record.Map()))
+	}
+	return result
+}
+
+func binaryBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,83 @@
+package gompd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// Watcher delivers the names of changed subsystems, like gompd's
+// Watcher.
+type Watcher struct {
+	Event chan string // closed when the watcher stops
+	Error chan error  // closed when the watcher stops
+
+	w    *mpd.Watcher
+	done chan struct{}
+}
+
+// NewWatcher() connects to the server and starts watching the given
+// subsystems, or all of them if none are given. Only the "tcp" network
+// is supported, and password must be empty.
+func NewWatcher(network, addr, password string, names ...string) (*Watcher, error) {
+	if network != "tcp" {
+		return nil, fmt.Errorf("unsupported network '%s'", network)
+	}
+	if password != "" {
+		return nil, errors.New("watcher passwords are not supported")
+	}
+	subsystems := make([]mpd.Subsystem, len(names))
+	for i, name := range names {
+		subsystems[i] = mpd.Subsystem(name)
+	}
+	w, err := mpd.NewWatcher(addr, subsystems...)
+	if err != nil {
+		return nil, err
+	}
+	watcher := &Watcher{
+		Event: make(chan string),
+		Error: make(chan error),
+		w:     w,
+		done:  make(chan struct{}),
+	}
+	w.Start()
+	go watcher.forward()
+	return watcher, nil
+}
+
+// Close() stops the watcher and closes its connection. It must only be
+// called once.
+func (w *Watcher) Close() error {
+	err := w.w.Close()
+	close(w.done)
+	return err
+}
+
+func (w *Watcher) forward() {
+	defer close(w.Event)
+	defer close(w.Error)
+	events, errs := w.w.Events, w.w.Errors
+	for events != nil || errs != nil {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			select {
+			case w.Event <- string(ev.Subsystem()):
+			case <-w.done:
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			select {
+			case w.Error <- err:
+			case <-w.done:
+			}
+		}
+	}
+}
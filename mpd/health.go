@@ -0,0 +1,43 @@
+package mpd
+
+import (
+	"time"
+)
+
+// HealthCheckTimeout is how long Healthy() waits for the server to answer.
+const HealthCheckTimeout = 2 * time.Second
+
+// Healthy() reports whether the connection is usable, by checking that
+// the server answers a ping within HealthCheckTimeout. It returns false
+// right away if the connection has been closed or lost, unless it is set
+// to reconnect, in which case the ping reconnects it first. Like any
+// command, the ping waits for commands in progress to finish.
+func (conn *Conn) Healthy() bool {
+	if conn.currentState() == StateDisconnected && !conn.canReconnect() {
+		return false
+	}
+	return conn.WithTimeout(HealthCheckTimeout).Ping() == nil
+}
+
+// LastUsed() returns when a command was last sent, or when the connection
+// was made if none have been.
+func (conn *Conn) LastUsed() time.Time {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	return conn.lastUsed
+}
+
+// LastError() returns the error that last broke the connection, or nil if
+// it has never been lost. It isn't cleared by reconnecting.
+func (conn *Conn) LastError() error {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	return conn.lastError
+}
+
+// touch() records that the connection is being used.
+func (s *session) touch() {
+	s.stateLock.Lock()
+	s.lastUsed = clockOrSystem(s.opts.clock).Now()
+	s.stateLock.Unlock()
+}
@@ -0,0 +1,57 @@
+package mpd
+
+import (
+	"strings"
+)
+
+// nonIdempotentCommands holds the commands whose effect compounds when
+// they are sent more than once. Every command not listed here, including
+// all commands that only read state, can be safely repeated.
+var nonIdempotentCommands = map[string]bool{
+	// queue
+	"add": true, "addid": true, "addtagid": true, "delete": true,
+	"deleteid": true, "move": true, "shuffle": true, "swap": true,
+	"swapid": true, "load": true, "findadd": true, "searchadd": true,
+
+	// stored playlists
+	"playlistadd": true, "playlistdelete": true, "playlistmove": true,
+	"rename": true, "rm": true, "save": true, "searchaddpl": true,
+
+	// playback and options
+	"next": true, "previous": true, "volume": true,
+	"toggleoutput": true,
+
+	// database and server
+	"update": true, "rescan": true, "sendmessage": true, "kill": true,
+	"newpartition": true, "delpartition": true,
+}
+
+// Idempotent() reports whether sending cmd more than once has the same
+// effect as sending it once, which makes it safe to retry when a
+// connection fails before its response arrives. Every method of Conn is
+// classified by the commands it sends, so callers wrapping them can use
+// this to make the same decisions the package's own retry logic makes.
+// A command list is idempotent only if every command in it is.
+func Idempotent(cmd string) bool {
+	for _, line := range strings.Split(cmd, "\n") {
+		args := splitArgs(line)
+		if len(args) > 0 && !idempotent(args[0], args[1:]) {
+			return false
+		}
+	}
+	return true
+}
+
+func idempotent(name string, args []string) bool {
+	switch name {
+	case "pause":
+		// Without an argument, pause toggles.
+		return len(args) > 0
+	case "seekcur":
+		// Relative seeks move further each time.
+		return len(args) == 0 || !strings.HasPrefix(args[0], "+") && !strings.HasPrefix(args[0], "-")
+	case "sticker":
+		return len(args) == 0 || args[0] != "inc" && args[0] != "dec"
+	}
+	return !nonIdempotentCommands[name]
+}
@@ -0,0 +1,78 @@
+package mpd_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"status", true},
+		{"play 3", true},
+		{"setvol 50", true},
+		{"pause 1", true},
+		{"pause", false},
+		{"seekcur 30", true},
+		{"seekcur +5", false},
+		{"seekcur -5", false},
+		{`sticker set song "a.flac" rating 5`, true},
+		{`sticker inc song "a.flac" playcount 1`, false},
+		{`add "a.flac"`, false},
+		{"next", false},
+		{"volume +5", false},
+		{`findadd "(Artist == \"X\")"`, false},
+		{"command_list_begin\nstatus\nsetvol 50\ncommand_list_end", true},
+		{"command_list_begin\nstatus\nnext\ncommand_list_end", false},
+	}
+	for _, test := range tests {
+		if got := mpd.Idempotent(test.cmd); got != test.want {
+			t.Errorf("Idempotent(%q) = %v, want %v", test.cmd, got, test.want)
+		}
+	}
+}
+
+// TestRetryIdempotent checks that a command interrupted by the connection
+// dropping is sent again after reconnecting only if it is idempotent.
+func TestRetryIdempotent(t *testing.T) {
+	tests := []struct {
+		cmd   string
+		retry bool
+	}{
+		{"setvol 50", true},
+		{"pause 1", true},
+		{"pause", false},
+		{"next", false},
+	}
+	for _, test := range tests {
+		srv := startServer(t)
+		var calls atomic.Int32
+		drop := func(args []string) ([]string, error) {
+			if calls.Add(1) == 1 {
+				srv.DropConnections()
+			}
+			return nil, nil
+		}
+		for _, name := range []string{"setvol", "pause", "next"} {
+			srv.Handle(name, drop)
+		}
+		conn := connect(t, srv, mpd.WithReconnect(mpd.ReconnectPolicy{InitialBackoff: time.Millisecond}))
+
+		_, err := conn.Send(test.cmd)
+		if test.retry && (err != nil || calls.Load() != 2) {
+			t.Errorf("%s was sent %d times and returned %v, want it retried", test.cmd, calls.Load(), err)
+		}
+		if !test.retry && (err == nil || calls.Load() != 1) {
+			t.Errorf("%s was sent %d times and returned %v, want it not retried", test.cmd, calls.Load(), err)
+		}
+		// Either way, the connection works again.
+		if err := conn.Ping(); err != nil {
+			t.Errorf("Ping() after %s: %v", test.cmd, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package mpd
+
+import (
+	"sync"
+)
+
+// Subsystem is a part of the server whose changes Idle() reports.
+type Subsystem string
+
+const (
+	DatabaseSubsystem       Subsystem = "database"        // the song database was modified after an update
+	UpdateSubsystem         Subsystem = "update"          // a database update started or finished
+	StoredPlaylistSubsystem Subsystem = "stored_playlist" // a stored playlist was modified, renamed, created or deleted
+	PlaylistSubsystem       Subsystem = "playlist"        // the queue was modified
+	PlayerSubsystem         Subsystem = "player"          // playback was started, stopped, paused or seeked
+	MixerSubsystem          Subsystem = "mixer"           // the volume changed
+	OutputSubsystem         Subsystem = "output"          // an output was added, removed, enabled or disabled
+	OptionsSubsystem        Subsystem = "options"         // an option such as repeat or random changed
+	PartitionSubsystem      Subsystem = "partition"       // a partition was added, removed or changed
+	StickerSubsystem        Subsystem = "sticker"         // a sticker was modified
+	SubscriptionSubsystem   Subsystem = "subscription"    // a client subscribed to or unsubscribed from a channel
+	MessageSubsystem        Subsystem = "message"         // a message arrived on a subscribed channel
+	NeighborSubsystem       Subsystem = "neighbor"        // a neighbor was found or lost
+	MountSubsystem          Subsystem = "mount"           // the mount list changed
+)
+
+// Idle() blocks until one of the given subsystems changes, or any of them
+// if none are given, and returns the subsystems that did. It is the basis
+// of event-driven clients; Watcher builds on it.
+//
+// Idle() waits as long as it takes, so to give up waiting, call it on a
+// view made by WithContext() and cancel the context.
+//
+// Other goroutines can keep using the connection while it idles: a
+// command sent meanwhile ends the idle with noidle, and once the command
+// has gone through, Idle() resumes waiting. Changes made in between are
+// still reported, since the server keeps track of them for the client.
+func (conn *Conn) Idle(subsystems ...Subsystem) ([]Subsystem, error) {
+	cmd := "idle"
+	for _, subsystem := range subsystems {
+		cmd += " " + string(subsystem)
+	}
+	for {
+		lines, err := conn.exec(cmd)
+		if err != nil {
+			return nil, err
+		}
+		var changed []Subsystem
+		for _, line := range lines {
+			if key, value, ok := splitPair(line); ok && key == "changed" {
+				changed = append(changed, Subsystem(value))
+			}
+		}
+		if len(changed) > 0 {
+			return changed, nil
+		}
+		// Nothing changed, so the idle was ended to let another command
+		// through.
+		conn.waitForWaiters()
+	}
+}
+
+// acquire() takes the lock for sending a command or changing the
+// connection's settings. If an idle is in progress, it is ended so that
+// the caller doesn't have to wait for a change.
+func (s *session) acquire() {
+	s.stateLock.Lock()
+	s.waiters++
+	s.stateLock.Unlock()
+
+	s.interruptIdle()
+	s.lock.Lock()
+
+	s.stateLock.Lock()
+	s.waiters--
+	if s.waiters == 0 && s.noWaiters != nil {
+		s.noWaiters.Broadcast()
+	}
+	s.stateLock.Unlock()
+}
+
+// waitForWaiters() waits until everyone waiting in acquire() has taken the
+// lock, so that an interrupted idle doesn't start again ahead of them.
+func (s *session) waitForWaiters() {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	for s.waiters > 0 {
+		if s.noWaiters == nil {
+			s.noWaiters = sync.NewCond(&s.stateLock)
+		}
+		s.noWaiters.Wait()
+	}
+}
+
+// startIdling() records that an idle has been sent and is waiting for a
+// response. If someone is already waiting for the lock, the idle is ended
+// right away.
+func (s *session) startIdling() {
+	s.stateLock.Lock()
+	s.idling = true
+	waiting := s.waiters > 0
+	s.stateLock.Unlock()
+	if waiting {
+		s.interruptIdle()
+	}
+}
+
+// stopIdling() records that an idle has returned.
+func (s *session) stopIdling() {
+	s.stateLock.Lock()
+	s.idling = false
+	s.stateLock.Unlock()
+}
+
+// interruptIdle() ends an idle in progress with noidle, which makes it
+// return right away. It does nothing if no idle is in progress.
+func (s *session) interruptIdle() {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	if !s.idling {
+		return
+	}
+	// While idling, nothing else writes to the connection, and the idle
+	// doesn't finish until stateLock is released.
+	s.idling = false
+	s.logSent("noidle")
+	s.out.WriteString("noidle\n")
+	s.out.Flush()
+}
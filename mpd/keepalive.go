@@ -0,0 +1,44 @@
+package mpd
+
+import (
+	"time"
+)
+
+// WithKeepAlive() sends a ping whenever the connection has gone unused for
+// about the given interval, so that MPD's connection_timeout doesn't close
+// it during long quiet periods, as happens with long-lived GUI clients.
+// The interval should be comfortably shorter than the server's timeout,
+// which defaults to 60 seconds.
+//
+// The pings never interrupt other commands: they are skipped while a
+// command is in progress, including while waiting in Idle(), during which
+// the server doesn't time the client out anyway. They stop once the
+// connection is closed, or lost without WithReconnect(). They are timed
+// by the Clock set with WithClock().
+func WithKeepAlive(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.keepAlive = interval
+	}
+}
+
+// keepAlive() pings the server until the connection is closed.
+func (conn *Conn) keepAlive(interval time.Duration) {
+	ticker := clockOrSystem(conn.opts.clock).NewTicker(interval / 2)
+	defer ticker.Stop()
+	for now := range ticker.C() {
+		if !conn.lock.TryLock() {
+			// Busy with a command, which keeps the connection alive.
+			continue
+		}
+		if conn.isClosed() || conn.currentState() == StateDisconnected && !conn.canReconnect() {
+			conn.lock.Unlock()
+			return
+		}
+		if conn.currentState() != StateDisconnected && now.Sub(conn.LastUsed()) >= interval/2 {
+			if _, broken, err := conn.roundTrip(nil, time.Time{}, "ping"); broken {
+				conn.disconnected("ping", err)
+			}
+		}
+		conn.lock.Unlock()
+	}
+}
@@ -0,0 +1,54 @@
+package mpd
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// WithLogger() logs every line sent to and received from the server at
+// debug level, for tracing protocol issues or auditing what is sent.
+// Passwords are redacted, and binary data, such as cover art, is logged
+// by its size only.
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *options) {
+		opts.logger = logger
+	}
+}
+
+// logSent() logs a command, or each line of a command list, being sent.
+func (s *session) logSent(cmd string) {
+	if !s.logging() {
+		return
+	}
+	for _, line := range strings.Split(cmd, "\n") {
+		s.opts.logger.Debug("mpd: sent", "line", redact(line))
+	}
+}
+
+// logReceived() logs a line received from the server.
+func (s *session) logReceived(line string) {
+	if s.logging() {
+		s.opts.logger.Debug("mpd: received", "line", line)
+	}
+}
+
+// logBinary() logs binary data received from the server.
+func (s *session) logBinary(data []byte) {
+	if s.logging() {
+		s.opts.logger.Debug("mpd: received", "line", "<"+strconv.Itoa(len(data))+" bytes of binary data>")
+	}
+}
+
+func (s *session) logging() bool {
+	return s.opts.logger != nil && s.opts.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// redact() hides the password sent by a password command.
+func redact(line string) string {
+	if args := splitArgs(line); len(args) > 1 && args[0] == "password" {
+		return "password ***"
+	}
+	return line
+}
@@ -3,24 +3,66 @@ package mpd
 import (
 	"bufio"
 	"bytes"
-	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
-// Conn represents a connection to the MPD server.
+// Conn represents a connection to the MPD server. It is safe to use from
+// several goroutines at once; their commands are sent one at a time.
 type Conn struct {
-	lock    sync.Mutex
-	socket  net.Conn
-	in      *bufio.Scanner
-	out     *bufio.Writer
-	version string // protocol version returned by the server
+	*session
+
+	// ctx bounds every command sent through this Conn, if non-nil. It is
+	// set on the views of the connection made by WithContext().
+	ctx context.Context
+
+	// deadline or timeout, if set, bound the response to each command
+	// sent through this Conn. They are set on the views made by
+	// WithDeadline() and WithTimeout().
+	deadline time.Time
+	timeout  time.Duration
+}
+
+// session holds the state of a connection, which is shared by a Conn and
+// all of its views.
+type session struct {
+	lock   sync.Mutex
+	socket net.Conn
+	in     *bufio.Reader
+	out    *bufio.Writer
+	addr   string   // address the connection was made to
+	addrs  []string // addresses to try when connecting
+	opts   options  // options the connection was made with
+
+	settings sessionSettings // replayed after reconnecting
+
+	listingTags []string // tag types to narrow bulk listings to
+	readOnly    bool     // reject commands that change server state
+	policy      Policy   // checked before sending each command
+
+	dryRun func(cmd string) // records skipped commands in dry-run mode
+
+	stateLock      sync.Mutex
+	state          ConnEvent
+	stateCallbacks []func(ConnEvent)
+	stateQueue     []queuedEvent
+	dispatching    bool
+	lastUsed       time.Time       // when a command was last sent
+	lastError      error           // the error that last broke the connection
+	closed         bool            // true once the connection has been closed
+	draining       bool            // true once Shutdown() has been called
+	idling         bool            // true while waiting for idle to return
+	waiters        int             // number of goroutines in acquire()
+	noWaiters      *sync.Cond      // signaled when waiters drops to zero
+	commands       map[string]bool // cached by Supports()
+	version        string          // protocol version returned by the server
 }
 
 type ReplayGainMode int
@@ -32,80 +74,418 @@ const (
 	ReplayGainAuto
 )
 
-var ackErrorPattern = regexp.MustCompile(`^ACK \[(\d+)@(\d+)\] \{(.*)\} (.*)$`)
-var patternLock sync.Mutex
+// Connect() connects to a running MPD instance. Addresses starting with
+// "/" are taken to be Unix socket paths and those starting with "@" to be
+// abstract socket names; anything else is dialed over TCP.
+func Connect(addr string) (*Conn, error) {
+	return ConnectWithOptions(addr)
+}
+
+// DialNetwork() connects to a running MPD instance over the given
+// network, such as "tcp" or "unix", as understood by net.Dial().
+func DialNetwork(network, addr string) (*Conn, error) {
+	return ConnectWithOptions(addr, WithNetwork(network))
+}
+
+// Version() returns the version of the protocol that was returned
+// when the connection was made, or last re-established.
+func (conn *Conn) Version() string {
+	return conn.serverVersion()
+}
 
-// Connect() connects to a running MPD instance.
-func Connect(addr string) (conn *Conn, err error) {
-	conn = new(Conn)
-	conn.socket, err = net.Dial("tcp", addr)
+// serverVersion() returns the protocol version, which changes when the
+// connection is re-established.
+func (s *session) serverVersion() string {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.version
+}
+
+// Send() is a low-level function for sending a raw command to the
+// MPD server. It should not end in a newline. This method should only
+// be used if none of the other methods will do what you want.
+func (conn *Conn) Send(cmd string) (Response, error) {
+	lines, err := conn.exec(cmd)
 	if err != nil {
 		return nil, err
 	}
-	conn.in = bufio.NewScanner(conn.socket)
-	conn.in.Split(bufio.ScanLines)
-	if ok := conn.in.Scan(); !ok {
-		err := conn.in.Err()
-		if err == nil {
-			err = io.ErrUnexpectedEOF
-		}
+	return parseResponse(lines), nil
+}
+
+// exec() sends a single command and collects every line of the response
+// up to, but not including, the terminating OK. If the server responds
+// with an ACK, the lines read before it are returned along with it.
+func (conn *Conn) exec(cmd string) ([]string, error) {
+	replies, err := conn.execAll([]string{cmd}, nil)
+	if err != nil {
 		return nil, err
 	}
-	resp := conn.in.Text()
-	if !strings.HasPrefix(resp, "OK MPD ") {
-		return nil, fmt.Errorf("unexpected MPD response: '%s'", resp)
-	}
-	conn.version = resp[7:]
-	if conn.version == "" {
-		return nil, errors.New("MPD reported empty version number")
+	return replies[0].lines, replies[0].err
+}
+
+// execEach() is like exec(), but passes each line of the response to fn
+// as it is read instead of collecting them. fn is called with the lock
+// held, so it must not send commands itself.
+func (conn *Conn) execEach(cmd string, fn func(line string)) error {
+	replies, err := conn.execAll([]string{cmd}, fn)
+	if err != nil {
+		return err
 	}
-	conn.out = bufio.NewWriter(conn.socket)
-	return conn, nil
+	return replies[0].err
 }
 
-// Version() returns the version of the protocol that was returned
-// when the connection was initially made.
-func (conn *Conn) Version() string {
-	return conn.version
+// reply is the response to one of several commands sent at once.
+type reply struct {
+	lines []string
+	err   error // the ACK, if the command failed
 }
 
-// Send() is a low-level function for sending a raw command to the
-// MPD server. It should not end in a newline. This method should only
-// be used if none of the other methods will do what you want.
-func (conn *Conn) Send(cmd string) (*list.List, error) {
-	conn.lock.Lock()
+// execAll() sends several commands at once and then reads their replies,
+// one per command. An error is only returned if none of the replies could
+// be read, such as when a command isn't allowed or the connection broke.
+// If each isn't nil, the lines of the replies are passed to it instead of
+// being collected.
+func (conn *Conn) execAll(cmds []string, each func(line string)) ([]reply, error) {
+	if len(cmds) == 1 && strings.HasPrefix(cmds[0], "idle") {
+		// An idle doesn't interrupt another one; it waits its turn.
+		conn.lock.Lock()
+	} else {
+		conn.acquire()
+	}
 	defer conn.lock.Unlock()
 
-	conn.out.WriteString(cmd + "\n")
-	conn.out.Flush()
-	resp := list.New()
-	for {
-		if ok := conn.in.Scan(); !ok {
-			err := conn.in.Err()
-			if err == nil {
-				err = io.EOF
+	replies := make([]reply, len(cmds))
+	var send []string
+	var sent []int // the index in cmds of each command in send
+	skips := make([]*dryRunSkips, len(cmds))
+	for i, cmd := range cmds {
+		if err := checkLines(cmd); err != nil {
+			return nil, err
+		}
+		if err := conn.checkCommand(cmd); err != nil {
+			return nil, err
+		}
+		if cmd, skips[i] = conn.filterDryRun(cmd); cmd != "" {
+			send = append(send, cmd)
+			sent = append(sent, i)
+		} else {
+			replies[i] = skips[i].fill(reply{})
+		}
+	}
+	if len(send) == 0 {
+		return replies, nil
+	}
+	cmd := strings.Join(send, "\n")
+
+	if conn.ctx != nil {
+		if err := conn.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if cmd == "close" {
+		if conn.markClosed() || conn.currentState() == StateDisconnected {
+			return replies, nil
+		}
+	} else if conn.isClosed() {
+		return nil, ErrClosed
+	}
+	if conn.currentState() == StateDisconnected {
+		if !conn.canReconnect() {
+			return nil, ErrBroken
+		}
+		if err := conn.reconnect(conn.ctx); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBroken, err)
+		}
+	}
+	results, broken, err := conn.roundTripAll(conn.ctx, conn.commandDeadline(cmd), send, each)
+	if broken {
+		conn.disconnected(cmd, err)
+		if cmd != "kill" && conn.canReconnect() && contextError(conn.ctx, err) == nil {
+			// Lines already passed to each can't be taken back, so
+			// those commands aren't retried.
+			if rerr := conn.reconnect(conn.ctx); rerr == nil && Idempotent(cmd) && each == nil {
+				results, broken, err = conn.roundTripAll(conn.ctx, conn.commandDeadline(cmd), send, nil)
+				if broken {
+					conn.disconnected(cmd, err)
+				}
+			}
+		}
+		if broken {
+			if err = conn.brokenError(cmd, err); err == nil {
+				return replies, nil
 			}
 			return nil, err
 		}
-		line := conn.in.Text()
+	}
+	for j, result := range results {
+		if result.err == nil {
+			conn.remember(send[j])
+			if strings.HasPrefix(send[j], "password ") {
+				conn.stateLock.Lock()
+				conn.commands = nil
+				conn.stateLock.Unlock()
+				conn.setState(ConnEvent{State: StateAuthenticated})
+			}
+		}
+		replies[sent[j]] = skips[sent[j]].fill(result)
+	}
+	return replies, nil
+}
+
+// roundTrip() sends a command and reads its response, bound by ctx if it
+// is non-nil and by deadline if it isn't zero. If the connection broke,
+// the socket is closed and broken is true. It must be called with the lock
+// held.
+func (s *session) roundTrip(ctx context.Context, deadline time.Time, cmd string) (resp []string, broken bool, err error) {
+	replies, broken, err := s.roundTripAll(ctx, deadline, []string{cmd}, nil)
+	if broken {
+		return nil, true, err
+	}
+	return replies[0].lines, false, replies[0].err
+}
+
+// roundTripAll() is like roundTrip(), but writes all of the commands
+// before reading any of the replies, so that they take a single round
+// trip between them. The data following a "binary: N" line is returned
+// as the next line of a reply. If each isn't nil, the lines are passed to
+// it instead.
+func (s *session) roundTripAll(ctx context.Context, deadline time.Time, cmds []string, each func(line string)) (replies []reply, broken bool, err error) {
+	if s.opts.writeTimeout > 0 {
+		s.socket.SetWriteDeadline(time.Now().Add(s.opts.writeTimeout))
+		defer s.socket.SetWriteDeadline(time.Time{})
+	}
+	s.touch()
+	for _, cmd := range cmds {
+		s.logSent(cmd)
+		s.out.WriteString(cmd + "\n")
+	}
+	if err := s.out.Flush(); err != nil {
+		s.socket.Close()
+		return nil, true, err
+	}
+	cmd := strings.Join(cmds, "\n")
+	idle := strings.HasPrefix(cmd, "idle")
+	if idle {
+		s.startIdling()
+		defer s.stopIdling()
+	}
+	if ctx != nil {
+		defer s.watchContext(ctx, cmd)()
+	}
+	ctxDeadline, hasCtxDeadline := contextDeadline(ctx)
+	if !deadline.IsZero() && (!hasCtxDeadline || deadline.Before(ctxDeadline)) {
+		s.socket.SetReadDeadline(deadline)
+		defer s.socket.SetReadDeadline(time.Time{})
+	}
+	var renew func()
+	if timeout := s.opts.readTimeout; timeout > 0 && deadline.IsZero() && !hasCtxDeadline && !idle {
+		renew = func() {
+			s.socket.SetReadDeadline(time.Now().Add(timeout))
+			if ctx != nil && ctx.Err() != nil {
+				// Don't undo watchContext() interrupting the read.
+				s.socket.SetReadDeadline(aLongTimeAgo)
+			}
+		}
+		defer s.socket.SetReadDeadline(time.Time{})
+	}
+	replies = make([]reply, len(cmds))
+	for i := range replies {
+		replies[i], err = s.readReply(each, renew)
+		if err != nil {
+			// Whatever went wrong, the rest of the response can't be
+			// read anymore, so the connection is no longer usable.
+			s.socket.Close()
+			if ctxErr := contextError(ctx, err); ctxErr != nil {
+				err = ctxErr
+			}
+			return nil, true, err
+		}
+	}
+	if idle && ctx != nil && replies[0].err == nil && ctx.Err() != nil {
+		replies[0].err = ctx.Err()
+	}
+	return replies, false, nil
+}
+
+// readReply() reads the response to a single command, passing each line
+// to each if it isn't nil. If renew isn't nil, it is called to extend the
+// read deadline before each line. An error is only returned if the
+// response couldn't be read in full.
+func (s *session) readReply(each func(line string), renew func()) (reply, error) {
+	var resp []string
+	add := func(line string) {
+		if each != nil {
+			each(line)
+		} else {
+			resp = append(resp, line)
+		}
+	}
+	if renew == nil {
+		renew = func() {}
+	}
+	for {
+		renew()
+		line, err := s.readLine()
+		if err != nil {
+			return reply{}, err
+		}
+		s.logReceived(line)
 		if line == "OK" {
-			return resp, nil
+			return reply{lines: resp}, nil
 		} else if strings.HasPrefix(line, "ACK ") {
-			return nil, newAckError(line)
+			// Return what was read so far, which for command lists is the
+			// output of the commands that succeeded.
+			ackErr, err := parseAckError(line)
+			if err != nil {
+				// The ACK still ends the response, so the connection
+				// remains usable.
+				return reply{resp, err}, nil
+			}
+			return reply{resp, ackErr}, nil
+		}
+		add(line)
+		if size, ok := strings.CutPrefix(line, "binary: "); ok {
+			renew()
+			data, err := s.readBinary(size)
+			if err != nil {
+				return reply{}, err
+			}
+			s.logBinary(data)
+			add(string(data))
+		}
+	}
+}
+
+// readBinary() reads a chunk of binary data of the given size, which is
+// followed by a newline.
+func (s *session) readBinary(size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid binary size: %q", size)
+	}
+	data := make([]byte, n+1)
+	if _, err := io.ReadFull(s.in, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
 		}
+		return nil, err
+	}
+	if data[n] != '\n' {
+		return nil, errors.New("binary data not followed by a newline")
+	}
+	return data[:n], nil
+}
+
+// readLine() reads the next line of a response, however long it is.
+func (s *session) readLine() (string, error) {
+	line, err := s.in.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Only very long lines, such as big comments or sticker values,
+		// outgrow the buffer, so they are the only ones copied around.
+		long := append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull {
+			line, err = s.in.ReadSlice('\n')
+			long = append(long, line...)
+		}
+		line = long
+	}
+	if err != nil {
+		if err == io.EOF && len(line) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	line = bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'})
+	return string(line), nil
+}
+
+// brokenError() returns the error for a command during which the
+// connection broke.
+func (conn *Conn) brokenError(cmd string, err error) error {
+	switch {
+	case cmd == "close", cmd == "kill":
+		// Both end the connection on purpose.
+		return nil
+	case conn.isClosed():
+		return ErrClosed
+	}
+	return fmt.Errorf("%w: %w", ErrBroken, err)
+}
+
+// disconnected() records that the connection was lost while sending cmd.
+func (conn *Conn) disconnected(cmd string, err error) {
+	conn.stateLock.Lock()
+	if cmd == "close" || cmd == "kill" || conn.closed {
+		err = nil
+	} else {
+		conn.lastError = err
 	}
+	conn.stateLock.Unlock()
+	conn.setState(ConnEvent{State: StateDisconnected, Err: err})
 }
 
 // SendList() is like Send(), but sends all of the commands at once
 // between command_list_begin and command_list_end.
-func (conn *Conn) SendList(cmds []string) (*list.List, error) {
+func (conn *Conn) SendList(cmds []string) (Response, error) {
+	return conn.Send(commandList(cmds))
+}
+
+// SendListOK() is like SendList(), but keeps the responses of the
+// commands apart, returning one per command. If one of them fails, the
+// responses of the commands before it are returned along with its index
+// in cmds and the error; otherwise the index is -1.
+func (conn *Conn) SendListOK(cmds []string) ([]Response, int, error) {
+	lines, err := conn.exec(commandListOK(cmds))
+	results := splitListOK(lines)
+	if err == nil {
+		return results, -1, nil
+	}
+	var ackErr *AckError
+	if errors.As(err, &ackErr) {
+		return results, ackErr.Index, err
+	}
+	// The list didn't get through, so there's no telling which command
+	// failed.
+	return results, len(results), err
+}
+
+// splitListOK() splits the response to a command list started with
+// command_list_ok_begin into the responses of each command, dropping
+// the output of a failed command, which has no list_OK after it.
+func splitListOK(lines []string) []Response {
+	var results []Response
+	start := 0
+	for i := 0; i < len(lines); i++ {
+		if lines[i] == "list_OK" {
+			results = append(results, parseResponse(lines[start:i]))
+			start = i + 1
+		} else if strings.HasPrefix(lines[i], "binary: ") {
+			// Skip the data, which might well read "list_OK".
+			i++
+		}
+	}
+	return results
+}
+
+// commandList() joins commands into a single command list.
+func commandList(cmds []string) string {
+	return joinCommandList("command_list_begin", cmds)
+}
+
+// commandListOK() joins commands into a single command list whose
+// response has a list_OK line after the output of each command.
+func commandListOK(cmds []string) string {
+	return joinCommandList("command_list_ok_begin", cmds)
+}
+
+func joinCommandList(begin string, cmds []string) string {
 	var buffer bytes.Buffer
-	buffer.WriteString("command_list_begin\n")
+	buffer.WriteString(begin + "\n")
 	for _, cmd := range cmds {
 		buffer.WriteString(cmd + "\n")
 	}
 	buffer.WriteString("command_list_end")
-	return conn.Send(buffer.String())
+	return buffer.String()
 }
 
 func (conn *Conn) SetConsume(consume bool) error {
@@ -113,13 +493,55 @@ func (conn *Conn) SetConsume(consume bool) error {
 	return err
 }
 
-// TODO: support floats?
-func (conn *Conn) SetCrossfade(seconds int64) error {
+// SetConsumeMode() sets consume mode, including to ConsumeOneshot, which
+// requires MPD 0.24.
+func (conn *Conn) SetConsumeMode(mode ConsumeMode) error {
+	arg, err := toggleModeArg("consume", int(mode))
+	if err != nil {
+		return err
+	}
+	if mode == ConsumeOneshot && !conn.ProtocolVersion().AtLeast(0, 24, 0) {
+		return errors.New("consume oneshot mode requires MPD 0.24")
+	}
+	_, err = conn.Send("consume " + arg)
+	return err
+}
+
+// SetConsumeOneshot() turns consume mode on for the current song only,
+// removing it from the queue once it has played.
+func (conn *Conn) SetConsumeOneshot() error {
+	return conn.SetConsumeMode(ConsumeOneshot)
+}
+
+// SetCrossfade() sets how long songs overlap, or turns crossfading off if
+// d is zero. The server only takes whole seconds, so d is rounded to the
+// nearest one, as Status().Crossfade reports it.
+func (conn *Conn) SetCrossfade(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("negative crossfade %s", d)
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
 	_, err := conn.Send("crossfade " + strconv.FormatInt(seconds, 10))
 	return err
 }
 
-// TODO: support mixramp?
+// SetMixRampDB() sets the volume, in decibels, at which songs overlap
+// when mixramp is in use. It is usually negative, such as -17.
+func (conn *Conn) SetMixRampDB(db float64) error {
+	_, err := conn.Send("mixrampdb " + strconv.FormatFloat(db, 'f', -1, 64))
+	return err
+}
+
+// SetMixRampDelay() sets the mixramp delay, which enables mixramp, or
+// disables it if delay is negative, as reported by Status().
+func (conn *Conn) SetMixRampDelay(delay time.Duration) error {
+	arg := "nan"
+	if delay >= 0 {
+		arg = formatSeconds(delay)
+	}
+	_, err := conn.Send("mixrampdelay " + arg)
+	return err
+}
 
 func (conn *Conn) SetRandom(random bool) error {
 	_, err := conn.Send("random " + binaryBool(random))
@@ -135,7 +557,7 @@ func (conn *Conn) SetVolume(vol int64) error {
 	if vol < 0 || vol > 100 {
 		return fmt.Errorf("volume level %d is outside valid range of 0-100", vol)
 	}
-	_, err := conn.Send("crossfade " + strconv.FormatInt(vol, 10))
+	_, err := conn.Send("setvol " + strconv.FormatInt(vol, 10))
 	return err
 }
 
@@ -144,21 +566,30 @@ func (conn *Conn) SetSingle(single bool) error {
 	return err
 }
 
+// SetSingleMode() sets single mode, including to SingleOneshot, which
+// requires MPD 0.21.
+func (conn *Conn) SetSingleMode(mode SingleMode) error {
+	arg, err := toggleModeArg("single", int(mode))
+	if err != nil {
+		return err
+	}
+	if mode == SingleOneshot && !conn.ProtocolVersion().AtLeast(0, 21, 0) {
+		return errors.New("single oneshot mode requires MPD 0.21")
+	}
+	_, err = conn.Send("single " + arg)
+	return err
+}
+
+// SetSingleOneshot() turns single mode on for the current song only.
+func (conn *Conn) SetSingleOneshot() error {
+	return conn.SetSingleMode(SingleOneshot)
+}
+
 func (conn *Conn) SetReplayGainMode(mode ReplayGainMode) error {
-	var modeString string
-	switch mode {
-	case ReplayGainOff:
-		modeString = "off"
-	case ReplayGainTrack:
-		modeString = "track"
-	case ReplayGainAlbum:
-		modeString = "album"
-	case ReplayGainAuto:
-		modeString = "auto"
-	default:
+	if _, ok := replayGainModeNames[mode]; !ok {
 		return fmt.Errorf("unknown replay gain mode '%d'", mode)
 	}
-	_, err := conn.Send("replay_gain_mode " + modeString)
+	_, err := conn.Send("replay_gain_mode " + mode.String())
 	return err
 }
 
@@ -167,11 +598,67 @@ func (conn *Conn) Ping() error {
 	return err
 }
 
-func (conn *Conn) Close() error {
-	_, err := conn.Send("close")
+// Kill() shuts down the server. The server drops the connection instead
+// of responding, which Kill() takes as success; an error means the server
+// refused, such as for lack of the admin permission. Later commands fail
+// until the server is back, and the connection doesn't reconnect on its
+// own after Kill(), though it does on the next command if WithReconnect()
+// allows.
+func (conn *Conn) Kill() error {
+	_, err := conn.exec("kill")
 	return err
 }
 
+// Close() closes the connection. It tells the server it's leaving if the
+// connection isn't busy, but doesn't wait for it, then closes the socket,
+// interrupting any command in progress in another goroutine, such as an
+// idle, which then fails with ErrClosed, as do commands sent afterwards.
+// Closing a connection that's already closed does nothing.
+func (conn *Conn) Close() error {
+	conn.stateLock.Lock()
+	if conn.closed {
+		conn.stateLock.Unlock()
+		return nil
+	}
+	conn.closed = true
+	socket := conn.socket
+	wasUp := conn.state.State != StateDisconnected
+	conn.stateLock.Unlock()
+
+	conn.setState(ConnEvent{State: StateDisconnected})
+	if conn.lock.TryLock() {
+		if wasUp {
+			socket.SetWriteDeadline(time.Now().Add(time.Second))
+			conn.logSent("close")
+			conn.out.WriteString("close\n")
+			conn.out.Flush()
+		}
+		defer conn.lock.Unlock()
+	}
+	if err := socket.Close(); err != nil && wasUp {
+		return err
+	}
+	return nil
+}
+
+// markClosed() records that the connection has been closed, so that it
+// isn't reconnected, and reports whether it already was.
+func (s *session) markClosed() bool {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	closed := s.closed
+	s.closed = true
+	return closed
+}
+
+// isClosed() reports whether the connection has been closed or is being
+// shut down.
+func (s *session) isClosed() bool {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.closed || s.draining
+}
+
 type Ack int
 
 const (
@@ -190,36 +677,130 @@ const (
 	ACK_ERROR_EXIST          Ack = 56
 )
 
+var ackNames = map[Ack]string{
+	ACK_ERROR_NOT_LIST:       "ACK_ERROR_NOT_LIST",
+	ACK_ERROR_ARG:            "ACK_ERROR_ARG",
+	ACK_ERROR_PASSWORD:       "ACK_ERROR_PASSWORD",
+	ACK_ERROR_PERMISSION:     "ACK_ERROR_PERMISSION",
+	ACK_ERROR_UNKNOWN:        "ACK_ERROR_UNKNOWN",
+	ACK_ERROR_NO_EXIST:       "ACK_ERROR_NO_EXIST",
+	ACK_ERROR_PLAYLIST_MAX:   "ACK_ERROR_PLAYLIST_MAX",
+	ACK_ERROR_SYSTEM:         "ACK_ERROR_SYSTEM",
+	ACK_ERROR_PLAYLIST_LOAD:  "ACK_ERROR_PLAYLIST_LOAD",
+	ACK_ERROR_UPDATE_ALREADY: "ACK_ERROR_UPDATE_ALREADY",
+	ACK_ERROR_PLAYER_SYNC:    "ACK_ERROR_PLAYER_SYNC",
+	ACK_ERROR_EXIST:          "ACK_ERROR_EXIST",
+}
+
+// String() returns the name of the ack code as used in MPD's source,
+// e.g. "ACK_ERROR_NO_EXIST", or the bare number if it isn't known.
+func (ack Ack) String() string {
+	if name, ok := ackNames[ack]; ok {
+		return name
+	}
+	return strconv.Itoa(int(ack))
+}
+
+// Error() makes ack codes usable as errors, so that an *AckError can be
+// matched against its code with errors.Is():
+//
+//	if errors.Is(err, mpd.ErrNoExist) {
+//		...
+//	}
+func (ack Ack) Error() string {
+	return ack.String()
+}
+
+// Sentinel errors for the ack codes, matched by errors.Is() against any
+// *AckError with the same code. A rejected password is reported as
+// ErrPassword instead by the methods that send one.
+var (
+	ErrNotList        error = ACK_ERROR_NOT_LIST
+	ErrArg            error = ACK_ERROR_ARG
+	ErrPermission     error = ACK_ERROR_PERMISSION
+	ErrUnknownCommand error = ACK_ERROR_UNKNOWN
+	ErrNoExist        error = ACK_ERROR_NO_EXIST
+	ErrPlaylistMax    error = ACK_ERROR_PLAYLIST_MAX
+	ErrSystem         error = ACK_ERROR_SYSTEM
+	ErrPlaylistLoad   error = ACK_ERROR_PLAYLIST_LOAD
+	ErrUpdateAlready  error = ACK_ERROR_UPDATE_ALREADY
+	ErrPlayerSync     error = ACK_ERROR_PLAYER_SYNC
+	ErrExist          error = ACK_ERROR_EXIST
+)
+
+// AckCode() returns the ack code of err if it is, or wraps, an
+// *AckError.
+func AckCode(err error) (Ack, bool) {
+	var ackErr *AckError
+	if errors.As(err, &ackErr) {
+		return ackErr.Ack, true
+	}
+	return 0, false
+}
+
 // AckError represents an error returned by MPD.
 type AckError struct {
-	errNum         Ack
-	commandNum     int
-	currentCommand string
-	message        string
+	Ack     Ack    // the error code
+	Index   int    // the position of the failed command in a command list, or 0
+	Command string // the name of the failed command, if the server gave it
+	Message string // the message describing the error
+}
+
+// Code() returns the ack code of the error.
+func (err *AckError) Code() Ack {
+	return err.Ack
+}
+
+// Is() reports whether target is the error's ack code, for errors.Is().
+func (err *AckError) Is(target error) bool {
+	code, ok := target.(Ack)
+	return ok && code == err.Ack
 }
 
+// CurrentCommand() returns the name of the failed command.
 func (err *AckError) CurrentCommand() string {
-	return err.currentCommand
+	return err.Command
 }
 
 func (err *AckError) Error() string {
-	return fmt.Sprintf("%d: %s", err.errNum, err.message)
-}
-
-// newAckError() parses an ACK error line into an AckError. It panics if
-// the line isn't well-formed.
-func newAckError(str string) *AckError {
-	patternLock.Lock()
-	defer patternLock.Unlock()
-	matches := ackErrorPattern.FindStringSubmatch(str)
-	if matches == nil {
-		panic(fmt.Sprintf("couldn't parse ACK error: '%s'", str))
-	}
-	errNum, _ := strconv.Atoi(matches[1])
-	commandNum, _ := strconv.Atoi(matches[2])
-	currentCommand := matches[3]
-	message := matches[4]
-	return &AckError{Ack(errNum), commandNum, currentCommand, message}
+	return fmt.Sprintf("%s: %s", err.Ack, err.Message)
+}
+
+// parseAckError() parses an ACK line, such as
+//
+//	ACK [50@0] {play} song doesn't exist: "10"
+//
+// into an AckError.
+func parseAckError(line string) (*AckError, error) {
+	malformed := fmt.Errorf("malformed ACK: %q", line)
+	rest, ok := strings.CutPrefix(line, "ACK [")
+	if !ok {
+		return nil, malformed
+	}
+	code, rest, ok := strings.Cut(rest, "@")
+	if !ok {
+		return nil, malformed
+	}
+	index, rest, ok := strings.Cut(rest, "] {")
+	if !ok {
+		return nil, malformed
+	}
+	command, message, ok := strings.Cut(rest, "} ")
+	if !ok {
+		// The message may be empty, leaving no space after the brace.
+		if command, ok = strings.CutSuffix(rest, "}"); !ok {
+			return nil, malformed
+		}
+	}
+	ack, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, malformed
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, malformed
+	}
+	return &AckError{Ack(ack), i, command, message}, nil
 }
 
 // binaryBool() converts a boolean value into either "1" or "0".
@@ -0,0 +1,44 @@
+package mpd
+
+import (
+	"testing"
+)
+
+func TestParseAckError(t *testing.T) {
+	tests := []struct {
+		line string
+		want AckError
+	}{
+		{`ACK [50@0] {play} song doesn't exist: "10240"`, AckError{ACK_ERROR_NO_EXIST, 0, "play", `song doesn't exist: "10240"`}},
+		{`ACK [5@2] {} unknown command "foo"`, AckError{ACK_ERROR_UNKNOWN, 2, "", `unknown command "foo"`}},
+		{`ACK [2@0] {seek}`, AckError{ACK_ERROR_ARG, 0, "seek", ""}},
+		{`ACK [4@0] {add} you don't have permission for "add"`, AckError{ACK_ERROR_PERMISSION, 0, "add", `you don't have permission for "add"`}},
+		{`ACK [2@0] {find} message with {braces} and ] brackets`, AckError{ACK_ERROR_ARG, 0, "find", "message with {braces} and ] brackets"}},
+	}
+	for _, test := range tests {
+		got, err := parseAckError(test.line)
+		if err != nil {
+			t.Errorf("parseAckError(%q): %v", test.line, err)
+			continue
+		}
+		if *got != test.want {
+			t.Errorf("parseAckError(%q) = %+v, want %+v", test.line, *got, test.want)
+		}
+	}
+}
+
+func TestParseAckErrorMalformed(t *testing.T) {
+	for _, line := range []string{
+		"ACK",
+		"ACK []",
+		"ACK [50] {play} missing index",
+		"ACK [x@0] {play} bad code",
+		"ACK [50@y] {play} bad index",
+		"ACK [50@0] play no braces",
+		"OK",
+	} {
+		if got, err := parseAckError(line); err == nil {
+			t.Errorf("parseAckError(%q) = %+v, want error", line, *got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package mpd
+
+import (
+	"time"
+)
+
+// PlaybackOptions holds the current values of the playback options.
+type PlaybackOptions struct {
+	Random     bool
+	Repeat     bool
+	Single     SingleMode
+	Consume    ConsumeMode
+	Crossfade  time.Duration
+	ReplayGain ReplayGainMode
+
+	MixRampDB    float64       // volume threshold in decibels
+	MixRampDelay time.Duration // negative if mixramp is disabled
+}
+
+// PlaybackOptions() fetches the current playback options.
+func (conn *Conn) PlaybackOptions() (PlaybackOptions, error) {
+	attrs, err := conn.attrs(commandList([]string{"status", "replay_gain_status"}))
+	if err != nil {
+		return PlaybackOptions{}, err
+	}
+	status := parseStatus(attrs)
+	opts := PlaybackOptions{
+		Random:       status.Random,
+		Repeat:       status.Repeat,
+		Single:       status.Single,
+		Consume:      status.Consume,
+		Crossfade:    status.Crossfade,
+		MixRampDB:    status.MixRampDB,
+		MixRampDelay: status.MixRampDelay,
+	}
+	opts.ReplayGain, _ = ParseReplayGainMode(attrs["replay_gain_mode"])
+	return opts, nil
+}
+
+// ReplayGainStatus() fetches the replay gain mode, as set by
+// SetReplayGainMode().
+func (conn *Conn) ReplayGainStatus() (ReplayGainMode, error) {
+	attrs, err := conn.attrs("replay_gain_status")
+	if err != nil {
+		return ReplayGainOff, err
+	}
+	return ParseReplayGainMode(attrs["replay_gain_mode"])
+}
+
+// OptionsChanged is delivered by a Watcher with ResolveOptions set when
+// the playback options change. The boolean fields report which of the
+// options are different from before.
+type OptionsChanged struct {
+	Options PlaybackOptions // the options after the change
+
+	Random     bool
+	Repeat     bool
+	Single     bool
+	Consume    bool
+	Crossfade  bool
+	ReplayGain bool
+	MixRamp    bool // either of the mixramp settings
+}
+
+func (ev OptionsChanged) Subsystem() Subsystem {
+	return OptionsSubsystem
+}
+
+// diffOptions() computes which options changed.
+func diffOptions(prev, cur PlaybackOptions) OptionsChanged {
+	return OptionsChanged{
+		Options:    cur,
+		Random:     cur.Random != prev.Random,
+		Repeat:     cur.Repeat != prev.Repeat,
+		Single:     cur.Single != prev.Single,
+		Consume:    cur.Consume != prev.Consume,
+		Crossfade:  cur.Crossfade != prev.Crossfade,
+		ReplayGain: cur.ReplayGain != prev.ReplayGain,
+		MixRamp:    cur.MixRampDB != prev.MixRampDB || cur.MixRampDelay != prev.MixRampDelay,
+	}
+}
+
+// ToggleRandom() turns random mode on if it is off and off if it is on,
+// returning the new setting.
+func (conn *Conn) ToggleRandom() (bool, error) {
+	opts, err := conn.PlaybackOptions()
+	if err != nil {
+		return false, err
+	}
+	return !opts.Random, conn.SetRandom(!opts.Random)
+}
+
+// ToggleRepeat() turns repeat mode on if it is off and off if it is on,
+// returning the new setting.
+func (conn *Conn) ToggleRepeat() (bool, error) {
+	opts, err := conn.PlaybackOptions()
+	if err != nil {
+		return false, err
+	}
+	return !opts.Repeat, conn.SetRepeat(!opts.Repeat)
+}
+
+// ToggleSingle() turns single mode on if it is off and off otherwise,
+// including when it is in oneshot mode, returning the new setting.
+func (conn *Conn) ToggleSingle() (bool, error) {
+	opts, err := conn.PlaybackOptions()
+	if err != nil {
+		return false, err
+	}
+	single := opts.Single == SingleOff
+	return single, conn.SetSingle(single)
+}
+
+// ToggleConsume() turns consume mode on if it is off and off otherwise,
+// including when it is in oneshot mode, returning the new setting.
+func (conn *Conn) ToggleConsume() (bool, error) {
+	opts, err := conn.PlaybackOptions()
+	if err != nil {
+		return false, err
+	}
+	consume := opts.Consume == ConsumeOff
+	return consume, conn.SetConsume(consume)
+}
@@ -0,0 +1,66 @@
+package mpd_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestWatcherResolveOptions(t *testing.T) {
+	srv := startServer(t)
+	var mu sync.Mutex
+	status := map[string]string{
+		"repeat": "1", "random": "0", "single": "0", "consume": "0",
+		"xfade": "0", "mixrampdb": "0", "mixrampdelay": "nan",
+	}
+	replayGain := "off"
+	srv.Handle("status", func(args []string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var lines []string
+		for key, value := range status {
+			lines = append(lines, key+": "+value)
+		}
+		return lines, nil
+	})
+	srv.Handle("replay_gain_status", func(args []string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []string{"replay_gain_mode: " + replayGain}, nil
+	})
+	w := startWatcher(t, srv, func(w *mpd.Watcher) {
+		w.ResolveOptions = true
+		w.SetSubsystems(mpd.OptionsSubsystem)
+	})
+	waitReceived(t, srv, "replay_gain_status")
+
+	mu.Lock()
+	status["random"] = "1"
+	status["single"] = "oneshot"
+	replayGain = "track"
+	mu.Unlock()
+	srv.Notify("options")
+	ev, ok := nextEvent(t, w).(mpd.OptionsChanged)
+	if !ok {
+		t.Fatalf("got %T, want OptionsChanged", ev)
+	}
+	want := mpd.OptionsChanged{Options: ev.Options, Random: true, Single: true, ReplayGain: true}
+	if ev != want {
+		t.Errorf("got %+v, want %+v", ev, want)
+	}
+	if opts := ev.Options; !opts.Random || !opts.Repeat || opts.Single != mpd.SingleOneshot || opts.ReplayGain != mpd.ReplayGainTrack {
+		t.Errorf("got options %+v", opts)
+	}
+
+	mu.Lock()
+	status["xfade"] = "5"
+	status["mixrampdb"] = "-17"
+	mu.Unlock()
+	srv.Notify("options")
+	ev = nextEvent(t, w).(mpd.OptionsChanged)
+	want = mpd.OptionsChanged{Options: ev.Options, Crossfade: true, MixRamp: true}
+	if ev != want {
+		t.Errorf("got %+v, want %+v", ev, want)
+	}
+}
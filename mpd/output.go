@@ -0,0 +1,87 @@
+package mpd
+
+import (
+	"strconv"
+)
+
+// Output represents an audio output.
+type Output struct {
+	ID      int
+	Name    string
+	Plugin  string
+	Enabled bool
+}
+
+// Outputs() returns the server's audio outputs.
+func (conn *Conn) Outputs() ([]Output, error) {
+	lines, err := conn.exec("outputs")
+	if err != nil {
+		return nil, err
+	}
+	var outputs []Output
+	for _, line := range lines {
+		key, value, ok := splitPair(line)
+		if !ok {
+			continue
+		}
+		if key == "outputid" {
+			id, _ := strconv.Atoi(value)
+			outputs = append(outputs, Output{ID: id})
+			continue
+		}
+		if len(outputs) == 0 {
+			continue
+		}
+		output := &outputs[len(outputs)-1]
+		switch key {
+		case "outputname":
+			output.Name = value
+		case "plugin":
+			output.Plugin = value
+		case "outputenabled":
+			output.Enabled = value == "1"
+		}
+	}
+	return outputs, nil
+}
+
+// OutputsChanged is delivered by a Watcher with ResolveOutputs set when
+// the outputs change.
+type OutputsChanged struct {
+	Outputs  []Output // the outputs after the change
+	Added    []Output
+	Removed  []Output
+	Enabled  []Output
+	Disabled []Output
+}
+
+func (ev OutputsChanged) Subsystem() Subsystem {
+	return OutputSubsystem
+}
+
+// diffOutputs() computes how the outputs changed, matching them by id.
+func diffOutputs(prev, cur []Output) OutputsChanged {
+	ev := OutputsChanged{Outputs: cur}
+	old := make(map[int]Output, len(prev))
+	for _, output := range prev {
+		old[output.ID] = output
+	}
+	for _, output := range cur {
+		before, ok := old[output.ID]
+		delete(old, output.ID)
+		switch {
+		case !ok:
+			ev.Added = append(ev.Added, output)
+		case output.Enabled && !before.Enabled:
+			ev.Enabled = append(ev.Enabled, output)
+		case !output.Enabled && before.Enabled:
+			ev.Disabled = append(ev.Disabled, output)
+		}
+	}
+	for _, output := range prev {
+		if _, ok := old[output.ID]; ok {
+			ev.Removed = append(ev.Removed, output)
+		}
+	}
+	return ev
+}
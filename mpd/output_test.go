@@ -0,0 +1,96 @@
+package mpd_test
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// fakeOutputs are the outputs of a fake server.
+type fakeOutputs struct {
+	mu      sync.Mutex
+	outputs []mpd.Output
+}
+
+func (o *fakeOutputs) set(outputs ...mpd.Output) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.outputs = outputs
+}
+
+func (o *fakeOutputs) handle(args []string) ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var lines []string
+	for _, output := range o.outputs {
+		enabled := "0"
+		if output.Enabled {
+			enabled = "1"
+		}
+		lines = append(lines,
+			"outputid: "+strconv.Itoa(output.ID),
+			"outputname: "+output.Name,
+			"plugin: "+output.Plugin,
+			"outputenabled: "+enabled)
+	}
+	return lines, nil
+}
+
+func outputIDs(outputs []mpd.Output) []int {
+	ids := []int{}
+	for _, output := range outputs {
+		ids = append(ids, output.ID)
+	}
+	return ids
+}
+
+func TestWatcherResolveOutputs(t *testing.T) {
+	alsa := mpd.Output{ID: 0, Name: "ALSA", Plugin: "alsa", Enabled: true}
+	pulse := mpd.Output{ID: 1, Name: "Pulse", Plugin: "pulse"}
+	stream := mpd.Output{ID: 2, Name: "Stream", Plugin: "httpd", Enabled: true}
+	fifo := mpd.Output{ID: 3, Name: "FIFO", Plugin: "fifo"}
+
+	srv := startServer(t)
+	outputs := &fakeOutputs{}
+	outputs.set(alsa, pulse, stream)
+	srv.Handle("outputs", outputs.handle)
+	w := startWatcher(t, srv, func(w *mpd.Watcher) {
+		w.ResolveOutputs = true
+		w.SetSubsystems(mpd.OutputSubsystem)
+	})
+	waitReceived(t, srv, "outputs")
+
+	alsa.Enabled, pulse.Enabled = false, true
+	outputs.set(alsa, pulse, fifo)
+	srv.Notify("output")
+	ev, ok := nextEvent(t, w).(mpd.OutputsChanged)
+	if !ok {
+		t.Fatalf("got %T, want OutputsChanged", ev)
+	}
+	tests := []struct {
+		name string
+		got  []mpd.Output
+		want []int
+	}{
+		{"outputs", ev.Outputs, []int{0, 1, 3}},
+		{"added", ev.Added, []int{3}},
+		{"removed", ev.Removed, []int{2}},
+		{"enabled", ev.Enabled, []int{1}},
+		{"disabled", ev.Disabled, []int{0}},
+	}
+	for _, test := range tests {
+		if got := outputIDs(test.got); !slices.Equal(got, test.want) {
+			t.Errorf("%s outputs are %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// Without a change, the next event reports none.
+	srv.Notify("output")
+	ev = nextEvent(t, w).(mpd.OutputsChanged)
+	if len(ev.Added)+len(ev.Removed)+len(ev.Enabled)+len(ev.Disabled) > 0 {
+		t.Errorf("got %+v after no change", ev)
+	}
+}
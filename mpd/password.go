@@ -0,0 +1,24 @@
+package mpd
+
+import (
+	"errors"
+)
+
+// ErrPassword is returned when the server rejects a password.
+var ErrPassword = errors.New("incorrect password")
+
+// Password() authenticates with the server, which grants whatever
+// permissions the password is configured with.
+func (conn *Conn) Password(password string) error {
+	_, err := conn.exec("password " + quote(password))
+	if code, ok := AckCode(err); ok && code == ACK_ERROR_PASSWORD {
+		return ErrPassword
+	}
+	return err
+}
+
+// ConnectWithPassword() connects like Connect() and then authenticates
+// with the given password.
+func ConnectWithPassword(addr, password string) (*Conn, error) {
+	return ConnectWithOptions(addr, WithPassword(password))
+}
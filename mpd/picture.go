@@ -0,0 +1,85 @@
+package mpd
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Picture holds cover art retrieved from the server.
+type Picture struct {
+	Data     []byte
+	MIMEType string // normalized MIME type, or empty if unknown
+	Ext      string // file extension including the dot, or empty if unknown
+}
+
+// NewPicture() wraps image data, filling in its MIME type and extension.
+// The type reported by the server, if any, is normalized and used as is;
+// otherwise the format is detected from the data itself.
+func NewPicture(data []byte, reportedType string) Picture {
+	mimeType := normalizeMIMEType(reportedType)
+	if mimeType == "" {
+		mimeType = DetectImageType(data)
+	}
+	return Picture{Data: data, MIMEType: mimeType, Ext: imageExtensions[mimeType]}
+}
+
+// imageExtensions maps image MIME types to their usual file extension.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+	"image/tiff": ".tiff",
+	"image/avif": ".avif",
+	"image/heic": ".heic",
+}
+
+// mimeAliases maps nonstandard MIME types found in tags to standard ones.
+var mimeAliases = map[string]string{
+	"image/jpg":      "image/jpeg",
+	"image/pjpeg":    "image/jpeg",
+	"image/x-png":    "image/png",
+	"image/x-ms-bmp": "image/bmp",
+	"image/x-bmp":    "image/bmp",
+}
+
+// normalizeMIMEType() lowercases a MIME type, drops any parameters and
+// maps common aliases to their standard form.
+func normalizeMIMEType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if alias, ok := mimeAliases[mimeType]; ok {
+		return alias
+	}
+	return mimeType
+}
+
+// DetectImageType() returns the MIME type of an image based on its
+// leading magic bytes, or the empty string if the format isn't known.
+func DetectImageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "image/bmp"
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "image/tiff"
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")):
+		switch string(data[8:12]) {
+		case "avif", "avis":
+			return "image/avif"
+		case "heic", "heix", "mif1":
+			return "image/heic"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package mpd
+
+import (
+	"errors"
+	"strings"
+)
+
+// pipelineBatch is how many commands a pipeline writes before reading
+// their replies. Writing without reading at all could deadlock once the
+// server stops reading because nobody reads its replies.
+const pipelineBatch = 256
+
+// Pipeline queues commands to be written to the connection together,
+// before reading any of their responses, which saves a round trip per
+// command over slow links, such as when filling a large queue. Unlike a
+// command list, the commands aren't run atomically, and each one
+// succeeds or fails on its own.
+type Pipeline struct {
+	conn *Conn
+	cmds []string
+}
+
+// PipelineResult is the outcome of a command sent through a pipeline.
+type PipelineResult struct {
+	Response Response
+	Err      error // the error returned by the server, if any
+}
+
+// NewPipeline() starts a pipeline to be sent on this connection.
+func (conn *Conn) NewPipeline() *Pipeline {
+	return &Pipeline{conn: conn}
+}
+
+// Queue() adds a raw command, as would be given to Send(), to the
+// pipeline. Nothing is sent until Collect() is called.
+func (p *Pipeline) Queue(cmd string) {
+	p.cmds = append(p.cmds, cmd)
+}
+
+// Len() returns the number of commands queued.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Collect() sends the queued commands and returns their results, in the
+// same order, emptying the pipeline. The error is only set if the results
+// couldn't be read, such as when the connection broke; results for the
+// commands that made it through before that are returned along with it.
+func (p *Pipeline) Collect() ([]PipelineResult, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	for _, cmd := range cmds {
+		if strings.HasPrefix(cmd, "idle") {
+			return nil, errors.New("idle can't be pipelined")
+		}
+	}
+	results := make([]PipelineResult, 0, len(cmds))
+	for len(cmds) > 0 {
+		batch := cmds[:min(len(cmds), pipelineBatch)]
+		cmds = cmds[len(batch):]
+		replies, err := p.conn.execAll(batch, nil)
+		if err != nil {
+			return results, err
+		}
+		for _, r := range replies {
+			results = append(results, PipelineResult{parseResponse(r.lines), r.err})
+		}
+	}
+	return results, nil
+}
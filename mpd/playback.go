@@ -0,0 +1,231 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Play() starts playing the song at the given position of the queue, or
+// resumes playback of the current song if pos is negative.
+func (conn *Conn) Play(pos int) error {
+	cmd := "play"
+	if pos >= 0 {
+		cmd += " " + strconv.Itoa(pos)
+	}
+	_, err := conn.exec(cmd)
+	return err
+}
+
+// PlayID() starts playing the song with the given id in the queue, or
+// resumes playback of the current song if id is negative.
+func (conn *Conn) PlayID(id SongID) error {
+	cmd := "playid"
+	if id >= 0 {
+		cmd += " " + strconv.Itoa(int(id))
+	}
+	_, err := conn.exec(cmd)
+	return err
+}
+
+// Pause() pauses playback, or resumes it if pause is false.
+func (conn *Conn) Pause(pause bool) error {
+	_, err := conn.exec("pause " + binaryBool(pause))
+	return err
+}
+
+// Toggle() pauses playback if it is playing and resumes it if it is
+// paused, like the play/pause key of a keyboard. If playback is stopped,
+// it starts playing the current song of the queue, or the first if there
+// is none. It returns the new state.
+func (conn *Conn) Toggle() (PlayerState, error) {
+	status, err := conn.Status()
+	if err != nil {
+		return Stopped, err
+	}
+	switch status.State {
+	case Playing:
+		return Paused, conn.Pause(true)
+	case Paused:
+		return Playing, conn.Pause(false)
+	}
+	return Playing, conn.Play(max(status.Song, 0))
+}
+
+// Stop() stops playback.
+func (conn *Conn) Stop() error {
+	_, err := conn.exec("stop")
+	return err
+}
+
+// Next() skips to the next song in the queue.
+func (conn *Conn) Next() error {
+	_, err := conn.exec("next")
+	return err
+}
+
+// Previous() goes back to the previous song in the queue.
+func (conn *Conn) Previous() error {
+	_, err := conn.exec("previous")
+	return err
+}
+
+// Seek() seeks to the given time in the song at the given position of
+// the queue, playing it.
+func (conn *Conn) Seek(pos int, t time.Duration) error {
+	if t < 0 {
+		return fmt.Errorf("negative seek time %s", t)
+	}
+	_, err := conn.exec("seek " + strconv.Itoa(pos) + " " + formatSeconds(t))
+	return err
+}
+
+// SeekID() seeks to the given time in the song with the given id,
+// playing it.
+func (conn *Conn) SeekID(id SongID, t time.Duration) error {
+	if t < 0 {
+		return fmt.Errorf("negative seek time %s", t)
+	}
+	_, err := conn.exec("seekid " + strconv.Itoa(int(id)) + " " + formatSeconds(t))
+	return err
+}
+
+// SeekCur() seeks to the given time in the current song.
+func (conn *Conn) SeekCur(t time.Duration) error {
+	if t < 0 {
+		return fmt.Errorf("negative seek time %s", t)
+	}
+	_, err := conn.exec("seekcur " + formatSeconds(t))
+	return err
+}
+
+// SeekCurBy() seeks forward in the current song by the given offset, or
+// back if it is negative, such as for skip buttons.
+func (conn *Conn) SeekCurBy(offset time.Duration) error {
+	arg := formatSeconds(offset)
+	if offset >= 0 {
+		arg = "+" + arg
+	}
+	_, err := conn.exec("seekcur " + arg)
+	return err
+}
+
+// PlayerChanged is delivered by a Watcher with ResolvePlayer set when
+// the player changes, such as when playback starts, stops or moves on to
+// another song.
+type PlayerChanged struct {
+	State  PlayerState // the state after the change
+	SongID SongID      // the current song after the change, or -1
+
+	PrevState  PlayerState // the state before the change
+	PrevSongID SongID      // the current song before the change, or -1
+}
+
+func (ev PlayerChanged) Subsystem() Subsystem {
+	return PlayerSubsystem
+}
+
+// StateChanged() reports whether the player changed between playing,
+// paused and stopped.
+func (ev PlayerChanged) StateChanged() bool {
+	return ev.State != ev.PrevState
+}
+
+// SongChanged() reports whether a different song became current.
+func (ev PlayerChanged) SongChanged() bool {
+	return ev.SongID != ev.PrevSongID
+}
+
+// songDuration() returns the duration of the current song as reported
+// by status, or zero if it is unknown, such as for streams.
+func songDuration(status map[string]string) time.Duration {
+	if d := parseFloatSeconds(status["duration"]); d > 0 {
+		return d
+	}
+	// Servers older than 0.20 only report "elapsed:total" in whole seconds.
+	if i := strings.IndexByte(status["time"], ':'); i >= 0 {
+		return parseSeconds(status["time"][i+1:])
+	}
+	return 0
+}
+
+// formatSeconds() formats a duration as fractional seconds for use as a
+// command argument.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// SeekPercent() seeks to the given percentage, from 0 to 100, of the
+// way through the current song, as a progress bar would.
+func (conn *Conn) SeekPercent(p float64) error {
+	if !(p >= 0 && p <= 100) { // also catches NaN
+		return fmt.Errorf("seek percentage %g is outside valid range of 0-100", p)
+	}
+	status, err := conn.Status()
+	if err != nil {
+		return err
+	}
+	if status.Song < 0 {
+		return errors.New("there is no current song to seek in")
+	}
+	if status.Duration == 0 {
+		return errors.New("the current song's duration is unknown")
+	}
+	return conn.SeekCur(time.Duration(float64(status.Duration) * p / 100))
+}
+
+// StopAfterCurrent() makes playback stop once the current song has
+// finished. On MPD 0.21 and later it turns on single oneshot mode, which
+// the server turns off again by itself. On older servers, it turns on
+// single mode, and repeat off, and restores them from a goroutine that
+// idles on the connection until playback stops or another song starts;
+// call it on a view made by WithContext() to be able to give up on that.
+func (conn *Conn) StopAfterCurrent() error {
+	if conn.ProtocolVersion().AtLeast(0, 21, 0) {
+		return conn.SetSingleOneshot()
+	}
+	status, err := conn.Status()
+	if err != nil {
+		return err
+	}
+	if status.State == Stopped {
+		return nil
+	}
+	if status.Repeat {
+		if err := conn.SetRepeat(false); err != nil {
+			return err
+		}
+	}
+	if status.Single == SingleOff {
+		if err := conn.SetSingle(true); err != nil {
+			return err
+		}
+	}
+	go conn.restoreAfterCurrent(status)
+	return nil
+}
+
+// restoreAfterCurrent() waits until the song playing in the given status
+// has finished and then restores its single and repeat modes.
+func (conn *Conn) restoreAfterCurrent(prev Status) {
+	for {
+		if _, err := conn.Idle(PlayerSubsystem); err != nil {
+			return
+		}
+		status, err := conn.Status()
+		if err != nil {
+			return
+		}
+		if status.State == Stopped || status.SongID != prev.SongID {
+			break
+		}
+	}
+	if prev.Single == SingleOff {
+		conn.SetSingle(false)
+	}
+	if prev.Repeat {
+		conn.SetRepeat(true)
+	}
+}
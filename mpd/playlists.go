@@ -0,0 +1,99 @@
+package mpd
+
+import (
+	"time"
+)
+
+// StoredPlaylist describes a stored playlist.
+type StoredPlaylist struct {
+	Name         string
+	LastModified time.Time
+}
+
+// ListPlaylists() returns the stored playlists.
+func (conn *Conn) ListPlaylists() ([]StoredPlaylist, error) {
+	lines, err := conn.exec("listplaylists")
+	if err != nil {
+		return nil, err
+	}
+	var playlists []StoredPlaylist
+	for _, line := range lines {
+		key, value, ok := splitPair(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case key == "playlist":
+			playlists = append(playlists, StoredPlaylist{Name: value})
+		case key == "Last-Modified" && len(playlists) > 0:
+			playlists[len(playlists)-1].LastModified, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	return playlists, nil
+}
+
+// PlaylistRename describes a stored playlist that was renamed.
+type PlaylistRename struct {
+	From, To string
+}
+
+// PlaylistsChanged is delivered by a Watcher with ResolvePlaylists set
+// when the stored playlists change. Since MPD doesn't report renames as
+// such, a playlist that disappeared and one that appeared with the same
+// modification time are taken to be a rename.
+type PlaylistsChanged struct {
+	Playlists []StoredPlaylist // the playlists after the change
+	Created   []StoredPlaylist
+	Deleted   []StoredPlaylist
+	Modified  []StoredPlaylist
+	Renamed   []PlaylistRename
+}
+
+func (ev PlaylistsChanged) Subsystem() Subsystem {
+	return StoredPlaylistSubsystem
+}
+
+// diffPlaylists() computes how the stored playlists changed.
+func diffPlaylists(prev, cur []StoredPlaylist) PlaylistsChanged {
+	ev := PlaylistsChanged{Playlists: cur}
+	old := make(map[string]StoredPlaylist, len(prev))
+	for _, pl := range prev {
+		old[pl.Name] = pl
+	}
+	var created []StoredPlaylist
+	for _, pl := range cur {
+		before, ok := old[pl.Name]
+		delete(old, pl.Name)
+		switch {
+		case !ok:
+			created = append(created, pl)
+		case !pl.LastModified.Equal(before.LastModified):
+			ev.Modified = append(ev.Modified, pl)
+		}
+	}
+	for _, pl := range created {
+		if from, ok := takeRenamed(prev, old, pl.LastModified); ok {
+			ev.Renamed = append(ev.Renamed, PlaylistRename{From: from, To: pl.Name})
+		} else {
+			ev.Created = append(ev.Created, pl)
+		}
+	}
+	for _, pl := range prev {
+		if _, ok := old[pl.Name]; ok {
+			ev.Deleted = append(ev.Deleted, pl)
+		}
+	}
+	return ev
+}
+
+// takeRenamed() finds a disappeared playlist with the given modification
+// time, removing it from gone and returning its name.
+func takeRenamed(prev []StoredPlaylist, gone map[string]StoredPlaylist, modified time.Time) (string, bool) {
+	for _, pl := range prev {
+		if _, ok := gone[pl.Name]; ok && pl.LastModified.Equal(modified) {
+			delete(gone, pl.Name)
+			return pl.Name, true
+		}
+	}
+	return "", false
+}
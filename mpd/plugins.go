@@ -0,0 +1,86 @@
+package mpd
+
+import (
+	"strings"
+)
+
+// URLHandlers() returns the URL schemes the server can play from, such as
+// "http://" or "nfs://".
+func (conn *Conn) URLHandlers() ([]string, error) {
+	lines, err := conn.exec("urlhandlers")
+	if err != nil {
+		return nil, err
+	}
+	var handlers []string
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "handler" {
+			handlers = append(handlers, value)
+		}
+	}
+	return handlers, nil
+}
+
+// HandlesURL() reports whether a URL uses one of the schemes returned by
+// URLHandlers(), meaning the server can at least try to play it.
+func HandlesURL(handlers []string, url string) bool {
+	for _, handler := range handlers {
+		if len(url) >= len(handler) && strings.EqualFold(url[:len(handler)], handler) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decoder is a decoder plugin of the server, along with the files it
+// handles.
+type Decoder struct {
+	Plugin    string
+	Suffixes  []string // file name suffixes, such as "flac"
+	MIMETypes []string // MIME types, such as "audio/flac"
+}
+
+// Decodes() reports whether the decoder handles files with the given
+// name, based on its suffix.
+func (d Decoder) Decodes(name string) bool {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return false
+	}
+	suffix := name[i+1:]
+	for _, s := range d.Suffixes {
+		if strings.EqualFold(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decoders() returns the decoder plugins of the server.
+func (conn *Conn) Decoders() ([]Decoder, error) {
+	lines, err := conn.exec("decoders")
+	if err != nil {
+		return nil, err
+	}
+	var decoders []Decoder
+	for _, line := range lines {
+		key, value, ok := splitPair(line)
+		if !ok {
+			continue
+		}
+		if key == "plugin" {
+			decoders = append(decoders, Decoder{Plugin: value})
+			continue
+		}
+		if len(decoders) == 0 {
+			continue
+		}
+		d := &decoders[len(decoders)-1]
+		switch key {
+		case "suffix":
+			d.Suffixes = append(d.Suffixes, value)
+		case "mime_type":
+			d.MIMETypes = append(d.MIMETypes, value)
+		}
+	}
+	return decoders, nil
+}
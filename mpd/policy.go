@@ -0,0 +1,81 @@
+package mpd
+
+import (
+	"strings"
+)
+
+// Policy decides whether a command may be sent. It is called with the
+// command's name and its unquoted arguments, and rejects the command by
+// returning a non-nil error, which is passed on to the caller. For
+// command lists, it is called once for every command in the list.
+type Policy func(name string, args []string) error
+
+// SetPolicy() installs a policy that every command sent on the
+// connection is checked against before it is sent, or removes it if
+// policy is nil. This lets applications implement their own
+// permissions, for example per user in a multi-user frontend.
+func (conn *Conn) SetPolicy(policy Policy) {
+	conn.acquire()
+	defer conn.lock.Unlock()
+	conn.policy = policy
+}
+
+// checkCommand() checks cmd, which may be a command list, against the
+// read-only setting and the policy. It must be called with the lock held.
+func (conn *Conn) checkCommand(cmd string) error {
+	if !conn.readOnly && conn.policy == nil {
+		return nil
+	}
+	for _, line := range strings.Split(cmd, "\n") {
+		args := splitArgs(line)
+		if len(args) == 0 {
+			continue
+		}
+		name, args := args[0], args[1:]
+		switch name {
+		case "command_list_begin", "command_list_ok_begin", "command_list_end":
+			continue
+		}
+		if conn.readOnly && isMutating(name, args) {
+			return &ReadOnlyError{Command: name}
+		}
+		if conn.policy != nil {
+			if err := conn.policy(name, args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitArgs() splits a command line into its arguments, removing quotes
+// and backslash escapes.
+func splitArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(line):
+			i++
+			arg.WriteByte(line[i])
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
@@ -0,0 +1,160 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Pool.Get() once the pool has been closed.
+var ErrPoolClosed = errors.New("mpd: pool closed")
+
+// Pool maintains up to a fixed number of connections to one server, so
+// that many goroutines can send commands concurrently instead of queueing
+// up on a single Conn. Connections are made as they are needed and kept
+// around once returned.
+type Pool struct {
+	// IdleTimeout is how long a connection may sit unused before it's
+	// considered stale and closed rather than handed out again. It
+	// defaults to 50 seconds, a little under MPD's default
+	// connection_timeout of 60 seconds, after which the server would have
+	// closed it anyway. Zero disables it.
+	IdleTimeout time.Duration
+
+	// PingAfter is how long a connection may sit unused before Get()
+	// checks it with Ping() before handing it out. It defaults to 10
+	// seconds.
+	PingAfter time.Duration
+
+	// Clock is used to track how long connections have been idle. It
+	// defaults to SystemClock.
+	Clock Clock
+
+	addr  string
+	opts  []Option
+	slots chan struct{}
+
+	lock   sync.Mutex
+	idle   []pooledConn
+	closed bool
+}
+
+type pooledConn struct {
+	conn  *Conn
+	since time.Time
+}
+
+// NewPool() creates a pool of at most size connections to addr, made with
+// the given options, except for WithResumeState(), which would restore
+// the playback state every time the pool connected.
+func NewPool(addr string, size int, opts ...Option) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	opts = append(slices.Clip(opts), func(opts *options) {
+		opts.resumeState = ""
+	})
+	return &Pool{
+		IdleTimeout: 50 * time.Second,
+		PingAfter:   10 * time.Second,
+		addr:        addr,
+		opts:        opts,
+		slots:       make(chan struct{}, size),
+	}
+}
+
+// Get() returns a connection from the pool, making a new one if none are
+// idle. If all of them are in use, it waits for one to be returned or for
+// ctx to be done. Every connection obtained with Get() must be given back
+// with Put().
+func (p *Pool) Get(ctx context.Context) (*Conn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	conn, err := p.get(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *Pool) get(ctx context.Context) (*Conn, error) {
+	clock := clockOrSystem(p.Clock)
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if len(p.idle) == 0 {
+			p.lock.Unlock()
+			return ConnectContext(ctx, p.addr, p.opts...)
+		}
+		// Take the most recently used connection, which is the least
+		// likely to have gone stale.
+		pc := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.lock.Unlock()
+
+		idle := clock.Now().Sub(pc.since)
+		if p.IdleTimeout > 0 && idle >= p.IdleTimeout {
+			pc.conn.Close()
+			continue
+		}
+		if idle >= p.PingAfter {
+			if err := pc.conn.WithContext(ctx).Ping(); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				pc.conn.Close()
+				continue
+			}
+		}
+		return pc.conn, nil
+	}
+}
+
+// Put() returns a connection obtained with Get() to the pool. Connections
+// that have been lost or closed are discarded.
+func (p *Pool) Put(conn *Conn) {
+	defer func() { <-p.slots }()
+
+	p.lock.Lock()
+	if p.closed || conn.currentState() == StateDisconnected {
+		p.lock.Unlock()
+		conn.Close()
+		return
+	}
+	p.idle = append(p.idle, pooledConn{conn: conn, since: clockOrSystem(p.Clock).Now()})
+	p.lock.Unlock()
+}
+
+// Do() runs fn with a connection from the pool, returning it afterwards.
+func (p *Pool) Do(ctx context.Context, fn func(*Conn) error) error {
+	conn, err := p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Put(conn)
+	return fn(conn)
+}
+
+// Close() closes the idle connections and makes further calls to Get()
+// fail. Connections that are in use are closed as they are returned.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.lock.Unlock()
+
+	for _, pc := range idle {
+		pc.conn.Close()
+	}
+	return nil
+}
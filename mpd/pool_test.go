@@ -0,0 +1,143 @@
+package mpd_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+func newPool(t *testing.T, srv *testutil.Server, size int) (*mpd.Pool, *testutil.Clock) {
+	t.Helper()
+	pool := mpd.NewPool(srv.Addr(), size)
+	clock := testutil.NewClock(epoch)
+	pool.Clock = clock
+	t.Cleanup(func() { pool.Close() })
+	return pool, clock
+}
+
+func get(t *testing.T, pool *mpd.Pool) *mpd.Conn {
+	t.Helper()
+	conn, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestPoolDefaults(t *testing.T) {
+	pool := mpd.NewPool("localhost:6600", 1)
+	// MPD closes connections idle for longer than its connection_timeout,
+	// 60 seconds by default, so the pool must give up on them first.
+	if pool.IdleTimeout <= 0 || pool.IdleTimeout >= time.Minute {
+		t.Errorf("IdleTimeout defaults to %v", pool.IdleTimeout)
+	}
+}
+
+func TestPoolReuse(t *testing.T) {
+	srv := startServer(t)
+	pool, _ := newPool(t, srv, 2)
+	first := get(t, pool)
+	pool.Put(first)
+	if conn := get(t, pool); conn != first {
+		t.Error("Get() didn't reuse the idle connection")
+	}
+}
+
+func TestPoolSize(t *testing.T) {
+	srv := startServer(t)
+	pool, _ := newPool(t, srv, 2)
+	a, b := get(t, pool), get(t, pool)
+	if a == b {
+		t.Fatal("Get() handed out a connection twice")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() on a full pool returned %v", err)
+	}
+	got := make(chan *mpd.Conn)
+	go func() { got <- get(t, pool) }()
+	pool.Put(a)
+	if conn := <-got; conn != a {
+		t.Error("a waiting Get() didn't receive the returned connection")
+	}
+	pool.Put(b)
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+	srv := startServer(t)
+	pool, clock := newPool(t, srv, 1)
+	first := get(t, pool)
+	pool.Put(first)
+
+	// A connection idle for a while is checked before being handed out.
+	clock.Advance(pool.PingAfter)
+	commands := srv.Commands()
+	if conn := get(t, pool); conn != first {
+		t.Error("Get() didn't reuse a connection idle for less than IdleTimeout")
+	} else if srv.Commands() == commands {
+		t.Error("Get() didn't ping a connection idle for PingAfter")
+	}
+	pool.Put(first)
+
+	// One idle for too long is closed instead.
+	clock.Advance(pool.IdleTimeout)
+	conn := get(t, pool)
+	if conn == first {
+		t.Error("Get() reused a connection idle for IdleTimeout")
+	}
+	if err := first.Ping(); err == nil {
+		t.Error("the stale connection wasn't closed")
+	}
+	pool.Put(conn)
+}
+
+func TestPoolDropped(t *testing.T) {
+	srv := startServer(t)
+	pool, clock := newPool(t, srv, 1)
+	first := get(t, pool)
+	pool.Put(first)
+
+	// A connection the server has dropped fails its ping and is replaced.
+	srv.DropConnections()
+	clock.Advance(pool.PingAfter)
+	conn := get(t, pool)
+	if conn == first {
+		t.Error("Get() handed out a dropped connection")
+	}
+	if err := conn.Ping(); err != nil {
+		t.Errorf("new connection: %v", err)
+	}
+
+	// So is one returned after it was lost.
+	srv.DropConnections()
+	conn.Ping()
+	pool.Put(conn)
+	if next := get(t, pool); next == conn {
+		t.Error("Get() handed out a lost connection")
+	} else {
+		pool.Put(next)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	srv := startServer(t)
+	pool, _ := newPool(t, srv, 2)
+	idle, busy := get(t, pool), get(t, pool)
+	pool.Put(idle)
+	pool.Close()
+	if err := idle.Ping(); err == nil {
+		t.Error("Close() didn't close the idle connection")
+	}
+	if _, err := pool.Get(context.Background()); !errors.Is(err, mpd.ErrPoolClosed) {
+		t.Errorf("Get() after Close() returned %v", err)
+	}
+	pool.Put(busy)
+	if err := busy.Ping(); err == nil {
+		t.Error("a connection returned after Close() wasn't closed")
+	}
+}
@@ -0,0 +1,73 @@
+package mpd
+
+// Feature is an optional protocol feature that a client can opt into or
+// out of on MPD 0.24 and later.
+type Feature string
+
+const (
+	// HidePlaylistsInRoot hides stored playlists from the listings of
+	// the root directory, such as lsinfo with no URI.
+	HidePlaylistsInRoot Feature = "hide_playlists_in_root"
+)
+
+// ProtocolFeatures() returns the protocol features enabled on this
+// connection. It requires MPD 0.24, like the other methods for protocol
+// features.
+func (conn *Conn) ProtocolFeatures() ([]Feature, error) {
+	return conn.featureList("protocol")
+}
+
+// AvailableProtocolFeatures() returns every protocol feature the server
+// supports.
+func (conn *Conn) AvailableProtocolFeatures() ([]Feature, error) {
+	return conn.featureList("protocol available")
+}
+
+func (conn *Conn) featureList(cmd string) ([]Feature, error) {
+	lines, err := conn.exec(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var features []Feature
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "feature" {
+			features = append(features, Feature(value))
+		}
+	}
+	return features, nil
+}
+
+// EnableProtocolFeatures() enables the given protocol features on this
+// connection. Like the other methods changing protocol features, the
+// selection is restored if the connection reconnects.
+func (conn *Conn) EnableProtocolFeatures(features ...Feature) error {
+	return conn.setFeatures("enable", features)
+}
+
+// DisableProtocolFeatures() disables the given protocol features on this
+// connection.
+func (conn *Conn) DisableProtocolFeatures(features ...Feature) error {
+	return conn.setFeatures("disable", features)
+}
+
+// AllProtocolFeatures() enables every protocol feature the server
+// supports.
+func (conn *Conn) AllProtocolFeatures() error {
+	_, err := conn.exec("protocol all")
+	return err
+}
+
+// ClearProtocolFeatures() disables every protocol feature.
+func (conn *Conn) ClearProtocolFeatures() error {
+	_, err := conn.exec("protocol clear")
+	return err
+}
+
+func (conn *Conn) setFeatures(action string, features []Feature) error {
+	args := []string{action}
+	for _, f := range features {
+		args = append(args, string(f))
+	}
+	_, err := conn.exec(formatCommand("protocol", args...))
+	return err
+}
@@ -0,0 +1,273 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Clear() removes every song from the queue, stopping playback.
+func (conn *Conn) Clear() error {
+	_, err := conn.exec("clear")
+	return err
+}
+
+// Crop() removes every song from the queue except the current one.
+func (conn *Conn) Crop() error {
+	status, err := conn.Status()
+	if err != nil {
+		return err
+	}
+	current, length := status.Song, status.PlaylistLength
+	if current < 0 {
+		return errors.New("there is no current song to crop around")
+	}
+	var cmds []string
+	// Delete the tail first so that the current song's position is still
+	// valid when deleting the head.
+	if current+1 < length {
+		cmds = append(cmds, "delete "+Range{current + 1, length}.String())
+	}
+	if current > 0 {
+		cmds = append(cmds, "delete "+Range{0, current}.String())
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	_, err = conn.SendList(cmds)
+	return err
+}
+
+// ReorderQueue() rearranges the queue so that its songs appear in the
+// given order, which must contain the id of every song in the queue
+// exactly once. The fewest possible moveid commands are sent together in
+// a single command list, so other clients never observe the queue in an
+// intermediate state.
+func (conn *Conn) ReorderQueue(newOrder []SongID) error {
+	songs, err := conn.Queue()
+	if err != nil {
+		return err
+	}
+	current := make([]SongID, len(songs))
+	for i, song := range songs {
+		current[i] = song.ID
+	}
+	cmds, err := reorderCommands(current, newOrder)
+	if err != nil || len(cmds) == 0 {
+		return err
+	}
+	_, err = conn.SendList(cmds)
+	return err
+}
+
+// reorderCommands() computes the moveid commands that transform current
+// into newOrder. Songs forming the longest run that is already in the
+// right relative order stay put; every other song is moved directly
+// behind its predecessor in newOrder.
+func reorderCommands(current, newOrder []SongID) ([]string, error) {
+	if len(current) != len(newOrder) {
+		return nil, fmt.Errorf("new order has %d songs, but the queue has %d", len(newOrder), len(current))
+	}
+	pos := make(map[SongID]int, len(current))
+	for i, id := range current {
+		pos[id] = i
+	}
+	seen := make(map[SongID]bool, len(newOrder))
+	positions := make([]int, len(newOrder))
+	for i, id := range newOrder {
+		p, ok := pos[id]
+		if !ok {
+			return nil, fmt.Errorf("song id %d is not in the queue", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("song id %d appears more than once", id)
+		}
+		seen[id] = true
+		positions[i] = p
+	}
+	keep := longestIncreasing(positions)
+
+	order := append([]SongID(nil), current...)
+	var cmds []string
+	for i, id := range newOrder {
+		if keep[i] {
+			continue
+		}
+		from := indexOfSong(order, id)
+		to := 0
+		if i > 0 {
+			to = indexOfSong(order, newOrder[i-1])
+			if from > to {
+				to++
+			}
+		}
+		if from == to {
+			continue
+		}
+		order = append(order[:from], order[from+1:]...)
+		order = append(order[:to], append([]SongID{id}, order[to:]...)...)
+		cmds = append(cmds, fmt.Sprintf("moveid %d %d", id, to))
+	}
+	return cmds, nil
+}
+
+// longestIncreasing() marks the elements of a longest strictly
+// increasing subsequence of values.
+func longestIncreasing(values []int) []bool {
+	// tails[k] is the index of the smallest value ending an increasing
+	// subsequence of length k+1; prev links each index to its predecessor.
+	var tails []int
+	prev := make([]int, len(values))
+	for i, v := range values {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if values[tails[mid]] < v {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		} else {
+			prev[i] = -1
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	keep := make([]bool, len(values))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// indexOfSong() returns the index of id in ids, or -1.
+func indexOfSong(ids []SongID, id SongID) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadNext() inserts the contents of a stored playlist immediately after
+// the current song, or appends it to the queue if there is no current
+// song.
+func (conn *Conn) LoadNext(name string) error {
+	status, err := conn.Status()
+	if err != nil {
+		return err
+	}
+	current := status.Song
+	if current < 0 {
+		_, err = conn.exec("load " + quote(name))
+		return err
+	}
+	if conn.ProtocolVersion().AtLeast(0, 23, 1) {
+		_, err = conn.exec("load " + quote(name) + " 0: +0")
+		return err
+	}
+
+	// Older servers can't load to a position, so add each song by hand.
+	lines, err := conn.exec("listplaylist " + quote(name))
+	if err != nil {
+		return err
+	}
+	var cmds []string
+	for _, line := range lines {
+		if key, uri, ok := splitPair(line); ok && key == "file" {
+			cmds = append(cmds, fmt.Sprintf("addid %s %d", quote(uri), current+1+len(cmds)))
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	_, err = conn.SendList(cmds)
+	return err
+}
+
+// AddAllID() adds songs to the end of the queue in a single round trip,
+// returning their new ids in the same order. If one of them can't be
+// added, the ids of the songs added before it are returned along with
+// the error.
+func (conn *Conn) AddAllID(uris []string) ([]SongID, error) {
+	if len(uris) == 0 {
+		return nil, nil
+	}
+	cmds := make([]string, len(uris))
+	for i, uri := range uris {
+		cmds[i] = "addid " + quote(uri)
+	}
+	lines, err := conn.exec(commandListOK(cmds))
+	ids := make([]SongID, 0, len(uris))
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "Id" {
+			id, _ := strconv.Atoi(value)
+			ids = append(ids, SongID(id))
+		}
+	}
+	return ids, err
+}
+
+// Position is where to put songs in the queue: either an absolute
+// position, or one relative to the current song.
+type Position struct {
+	pos      int
+	relative bool
+}
+
+// AtPos() returns the given absolute position in the queue.
+func AtPos(pos int) Position {
+	return Position{pos: pos}
+}
+
+// AfterCurrent() returns the position offset songs after the one right
+// after the current song, so AfterCurrent(0) plays next.
+func AfterCurrent(offset int) Position {
+	return Position{pos: offset, relative: true}
+}
+
+// BeforeCurrent() returns the position offset songs before the current
+// song, so BeforeCurrent(0) pushes the current song down by one.
+func BeforeCurrent(offset int) Position {
+	return Position{pos: -offset - 1, relative: true}
+}
+
+// String() renders the position as the server expects it, such as "5",
+// "+0" or "-1".
+func (p Position) String() string {
+	switch {
+	case !p.relative:
+		return strconv.Itoa(p.pos)
+	case p.pos >= 0:
+		return "+" + strconv.Itoa(p.pos)
+	}
+	return "-" + strconv.Itoa(-p.pos-1)
+}
+
+// Add() adds a song, or a directory recursively, to the end of the queue.
+func (conn *Conn) Add(uri string) error {
+	_, err := conn.exec(formatCommand("add", uri))
+	return err
+}
+
+// AddAt() adds a song, or a directory recursively, at the given position
+// of the queue. It requires MPD 0.23.3.
+func (conn *Conn) AddAt(uri string, pos Position) error {
+	if !pos.relative && pos.pos < 0 {
+		return fmt.Errorf("invalid queue position %d", pos.pos)
+	}
+	if !conn.ProtocolVersion().AtLeast(0, 23, 3) {
+		return errors.New("adding at a position requires MPD 0.23.3")
+	}
+	_, err := conn.exec(formatCommand("add", uri, pos.String()))
+	return err
+}
@@ -0,0 +1,396 @@
+package mpd_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+// applyMoveIDs() performs moveid commands on a list of song ids, as the
+// server would.
+func applyMoveIDs(t *testing.T, order []mpd.SongID, cmds []string) []mpd.SongID {
+	t.Helper()
+	order = slices.Clone(order)
+	for _, cmd := range cmds {
+		var id mpd.SongID
+		var to int
+		if _, err := fmt.Sscanf(cmd, "moveid %d %d", &id, &to); err != nil {
+			t.Fatalf("unexpected command %q", cmd)
+		}
+		from := slices.Index(order, id)
+		order = slices.Delete(order, from, from+1)
+		order = slices.Insert(order, to, id)
+	}
+	return order
+}
+
+func TestReorderCommands(t *testing.T) {
+	tests := []struct {
+		current, newOrder []mpd.SongID
+		moves             int
+	}{
+		{nil, nil, 0},
+		{[]mpd.SongID{1}, []mpd.SongID{1}, 0},
+		{[]mpd.SongID{1, 2, 3}, []mpd.SongID{1, 2, 3}, 0},
+		{[]mpd.SongID{1, 2, 3}, []mpd.SongID{3, 2, 1}, 2},
+		{[]mpd.SongID{1, 2, 3, 4}, []mpd.SongID{4, 1, 2, 3}, 1},
+		{[]mpd.SongID{1, 2, 3, 4}, []mpd.SongID{2, 3, 4, 1}, 1},
+		{[]mpd.SongID{1, 2, 3, 4, 5}, []mpd.SongID{2, 1, 4, 3, 5}, 2},
+		{[]mpd.SongID{10, 20, 30, 40, 50, 60}, []mpd.SongID{60, 30, 10, 50, 20, 40}, 3},
+	}
+	for _, test := range tests {
+		cmds, err := mpd.ReorderCommands(test.current, test.newOrder)
+		if err != nil {
+			t.Errorf("ReorderCommands(%v, %v): %v", test.current, test.newOrder, err)
+			continue
+		}
+		if len(cmds) != test.moves {
+			t.Errorf("ReorderCommands(%v, %v) = %q, want %d moves", test.current, test.newOrder, cmds, test.moves)
+		}
+		if got := applyMoveIDs(t, test.current, cmds); !slices.Equal(got, test.newOrder) {
+			t.Errorf("ReorderCommands(%v, %v) = %q, which gives %v", test.current, test.newOrder, cmds, got)
+		}
+	}
+}
+
+func TestReorderCommandsInvalid(t *testing.T) {
+	tests := []struct {
+		current, newOrder []mpd.SongID
+	}{
+		{[]mpd.SongID{1, 2}, []mpd.SongID{1}},
+		{[]mpd.SongID{1, 2}, []mpd.SongID{1, 3}},
+		{[]mpd.SongID{1, 2}, []mpd.SongID{1, 1}},
+	}
+	for _, test := range tests {
+		if cmds, err := mpd.ReorderCommands(test.current, test.newOrder); err == nil {
+			t.Errorf("ReorderCommands(%v, %v) = %q, want error", test.current, test.newOrder, cmds)
+		}
+	}
+}
+
+// fakeQueue is the queue of a fake server, kept up to date by the
+// handlers for the commands that read and change it.
+type fakeQueue struct {
+	mu      sync.Mutex
+	files   []string
+	ids     []mpd.SongID
+	nextID  mpd.SongID
+	current int // the position of the current song, or -1
+
+	playlists map[string][]string // stored playlists by name
+}
+
+// newFakeQueue() fills the queue of srv with the given files, with the
+// song at current being played.
+func newFakeQueue(srv *testutil.Server, current int, files ...string) *fakeQueue {
+	q := &fakeQueue{current: -1, nextID: 1, playlists: make(map[string][]string)}
+	for _, file := range files {
+		q.add(file, len(q.files))
+	}
+	q.current = current
+	srv.Handle("status", q.handle(q.status))
+	srv.Handle("playlistinfo", q.handle(q.playlistInfo))
+	srv.Handle("moveid", q.handle(q.moveID))
+	srv.Handle("delete", q.handle(q.delete))
+	srv.Handle("addid", q.handle(q.addID))
+	srv.Handle("load", q.handle(q.load))
+	srv.Handle("listplaylist", q.handle(q.listPlaylist))
+	return q
+}
+
+// handle() makes a handler out of a method of the queue, which is called
+// with the queue locked.
+func (q *fakeQueue) handle(fn func(args []string) ([]string, error)) testutil.HandlerFunc {
+	return func(args []string) ([]string, error) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return fn(args)
+	}
+}
+
+// Files() returns the files in the queue, in order.
+func (q *fakeQueue) Files() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.files)
+}
+
+// SetPlaylist() stores a playlist.
+func (q *fakeQueue) SetPlaylist(name string, files ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.playlists[name] = files
+}
+
+// IDs() returns the ids of the songs in the queue, in order.
+func (q *fakeQueue) IDs() []mpd.SongID {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.ids)
+}
+
+func (q *fakeQueue) add(file string, pos int) mpd.SongID {
+	id := q.nextID
+	q.nextID++
+	q.files = slices.Insert(q.files, pos, file)
+	q.ids = slices.Insert(q.ids, pos, id)
+	if q.current >= pos {
+		q.current++
+	}
+	return id
+}
+
+func (q *fakeQueue) status(args []string) ([]string, error) {
+	lines := []string{"playlistlength: " + strconv.Itoa(len(q.files))}
+	if q.current >= 0 {
+		lines = append(lines,
+			"state: play",
+			"song: "+strconv.Itoa(q.current),
+			"songid: "+strconv.Itoa(int(q.ids[q.current])))
+	}
+	return lines, nil
+}
+
+func (q *fakeQueue) playlistInfo(args []string) ([]string, error) {
+	var lines []string
+	for i, file := range q.files {
+		lines = append(lines, "file: "+file, "Pos: "+strconv.Itoa(i), "Id: "+strconv.Itoa(int(q.ids[i])))
+	}
+	return lines, nil
+}
+
+func (q *fakeQueue) moveID(args []string) ([]string, error) {
+	id, _ := strconv.Atoi(args[0])
+	to, _ := strconv.Atoi(args[1])
+	from := slices.Index(q.ids, mpd.SongID(id))
+	if from < 0 || to < 0 || to >= len(q.ids) {
+		return nil, &testutil.Ack{Code: 50, Message: "No such song"}
+	}
+	var current mpd.SongID
+	if q.current >= 0 {
+		current = q.ids[q.current]
+	}
+	file := q.files[from]
+	q.files = slices.Insert(slices.Delete(q.files, from, from+1), to, file)
+	q.ids = slices.Insert(slices.Delete(q.ids, from, from+1), to, mpd.SongID(id))
+	if q.current >= 0 {
+		q.current = slices.Index(q.ids, current)
+	}
+	return nil, nil
+}
+
+// delete() handles a delete of a single position or a range.
+func (q *fakeQueue) delete(args []string) ([]string, error) {
+	start, end, ok := strings.Cut(args[0], ":")
+	from, err := strconv.Atoi(start)
+	to := from + 1
+	if ok && end == "" {
+		to = len(q.files)
+	} else if ok {
+		to, err = strconv.Atoi(end)
+	}
+	if err != nil || from < 0 || from >= to || to > len(q.files) {
+		return nil, &testutil.Ack{Code: 2, Message: "Bad song index"}
+	}
+	q.files = slices.Delete(q.files, from, to)
+	q.ids = slices.Delete(q.ids, from, to)
+	switch {
+	case q.current >= to:
+		q.current -= to - from
+	case q.current >= from:
+		q.current = -1
+	}
+	return nil, nil
+}
+
+// position() parses the position to add songs at, which may be relative
+// to the current song, or returns the end of the queue if there's none.
+func (q *fakeQueue) position(args []string, i int) (int, error) {
+	if len(args) <= i {
+		return len(q.files), nil
+	}
+	arg := args[i]
+	if arg[0] == '+' {
+		n, err := strconv.Atoi(arg[1:])
+		if err != nil || q.current < 0 {
+			return 0, &testutil.Ack{Code: 2, Message: "Bad position"}
+		}
+		return q.current + 1 + n, nil
+	}
+	pos, err := strconv.Atoi(arg)
+	if err != nil || pos < 0 || pos > len(q.files) {
+		return 0, &testutil.Ack{Code: 2, Message: "Bad position"}
+	}
+	return pos, nil
+}
+
+func (q *fakeQueue) addID(args []string) ([]string, error) {
+	pos, err := q.position(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"Id: " + strconv.Itoa(int(q.add(args[0], pos)))}, nil
+}
+
+// load() handles loading a whole playlist, which is all LoadNext() does.
+func (q *fakeQueue) load(args []string) ([]string, error) {
+	files, ok := q.playlists[args[0]]
+	if !ok {
+		return nil, &testutil.Ack{Code: 50, Message: "No such playlist"}
+	}
+	if len(args) > 1 && args[1] != "0:" {
+		return nil, &testutil.Ack{Code: 2, Message: "unexpected range " + args[1]}
+	}
+	pos, err := q.position(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		q.add(file, pos+i)
+	}
+	return nil, nil
+}
+
+func (q *fakeQueue) listPlaylist(args []string) ([]string, error) {
+	files, ok := q.playlists[args[0]]
+	if !ok {
+		return nil, &testutil.Ack{Code: 50, Message: "No such playlist"}
+	}
+	var lines []string
+	for _, file := range files {
+		lines = append(lines, "file: "+file)
+	}
+	return lines, nil
+}
+
+func TestCrop(t *testing.T) {
+	tests := []struct {
+		current int
+		want    string
+	}{
+		{0, "a"},
+		{2, "c"},
+		{4, "e"},
+	}
+	for _, test := range tests {
+		srv := startServer(t)
+		q := newFakeQueue(srv, test.current, "a", "b", "c", "d", "e")
+		conn := connect(t, srv)
+		if err := conn.Crop(); err != nil {
+			t.Errorf("Crop() around %d: %v", test.current, err)
+			continue
+		}
+		if got := q.Files(); !slices.Equal(got, []string{test.want}) {
+			t.Errorf("Crop() around %d left %q, want %q", test.current, got, test.want)
+		}
+	}
+}
+
+func TestCropSingleSong(t *testing.T) {
+	srv := startServer(t)
+	q := newFakeQueue(srv, 0, "a")
+	conn := connect(t, srv)
+	if err := conn.Crop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := q.Files(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Crop() left %q", got)
+	}
+	if got := srv.Received(); slices.ContainsFunc(got, func(cmd string) bool { return strings.HasPrefix(cmd, "delete") }) {
+		t.Errorf("Crop() sent %q with nothing to delete", got)
+	}
+}
+
+func TestCropNoCurrentSong(t *testing.T) {
+	srv := startServer(t)
+	q := newFakeQueue(srv, -1, "a", "b")
+	conn := connect(t, srv)
+	if err := conn.Crop(); err == nil {
+		t.Error("Crop() without a current song succeeded")
+	}
+	if got := q.Files(); len(got) != 2 {
+		t.Errorf("failed Crop() left %q", got)
+	}
+}
+
+func TestReorderQueue(t *testing.T) {
+	srv := startServer(t)
+	q := newFakeQueue(srv, 0, "a", "b", "c", "d", "e")
+	conn := connect(t, srv)
+
+	newOrder := []mpd.SongID{5, 2, 1, 4, 3}
+	if err := conn.ReorderQueue(newOrder); err != nil {
+		t.Fatal(err)
+	}
+	if got := q.IDs(); !slices.Equal(got, newOrder) {
+		t.Errorf("queue is %v, want %v", got, newOrder)
+	}
+	if got, want := q.Files(), []string{"e", "b", "a", "d", "c"}; !slices.Equal(got, want) {
+		t.Errorf("queue is %q, want %q", got, want)
+	}
+
+	if err := conn.ReorderQueue([]mpd.SongID{1, 2}); err == nil {
+		t.Error("ReorderQueue() accepted an order missing songs")
+	}
+	if got := q.IDs(); !slices.Equal(got, newOrder) {
+		t.Errorf("a rejected order changed the queue to %v", got)
+	}
+}
+
+func TestLoadNext(t *testing.T) {
+	tests := []struct {
+		version string
+		current int
+		want    []string
+	}{
+		{testutil.Version, 1, []string{"a", "b", "x", "y", "c"}},
+		{testutil.Version, 2, []string{"a", "b", "c", "x", "y"}},
+		{testutil.Version, -1, []string{"a", "b", "c", "x", "y"}},
+		// Older servers can't load to a position.
+		{"0.22.0", 0, []string{"a", "x", "y", "b", "c"}},
+		{"0.22.0", 2, []string{"a", "b", "c", "x", "y"}},
+		{"0.22.0", -1, []string{"a", "b", "c", "x", "y"}},
+	}
+	for _, test := range tests {
+		srv := startServer(t)
+		srv.SetVersion(test.version)
+		q := newFakeQueue(srv, test.current, "a", "b", "c")
+		q.SetPlaylist("mix", "x", "y")
+		conn := connect(t, srv)
+		if err := conn.LoadNext("mix"); err != nil {
+			t.Errorf("LoadNext() on %s after %d: %v", test.version, test.current, err)
+			continue
+		}
+		if got := q.Files(); !slices.Equal(got, test.want) {
+			t.Errorf("LoadNext() on %s after %d gave %q, want %q", test.version, test.current, got, test.want)
+		}
+		loadAt := slices.ContainsFunc(srv.Received(), func(cmd string) bool {
+			return strings.HasPrefix(cmd, "load ") && strings.Count(cmd, " ") > 1
+		})
+		if loadAt && test.version != testutil.Version {
+			t.Errorf("LoadNext() sent load with a position to a %s server", test.version)
+		}
+	}
+}
+
+func TestLoadNextMissing(t *testing.T) {
+	for _, version := range []string{testutil.Version, "0.22.0"} {
+		srv := startServer(t)
+		srv.SetVersion(version)
+		q := newFakeQueue(srv, 0, "a")
+		conn := connect(t, srv)
+		if err := conn.LoadNext("missing"); !errors.Is(err, mpd.ErrNoExist) {
+			t.Errorf("LoadNext() of a missing playlist on %s returned %v, want ErrNoExist", version, err)
+		}
+		if got := q.Files(); len(got) != 1 {
+			t.Errorf("failed LoadNext() on %s changed the queue to %q", version, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mpd
+
+import (
+	"time"
+)
+
+// QueueStats summarizes the contents of the queue.
+type QueueStats struct {
+	Songs     int
+	Total     time.Duration // duration of every song in the queue
+	Remaining time.Duration // duration of the songs after the current one
+	ByArtist  map[string]int
+	ByAlbum   map[string]int
+}
+
+// SummarizeQueue() computes statistics for the given queue. Current is
+// the position of the current song, or -1 if there is none, in which
+// case Remaining covers the whole queue. Songs without an Artist or
+// Album tag are counted under the empty string, and songs with several
+// artists are counted once for each.
+func SummarizeQueue(songs []Song, current int) QueueStats {
+	stats := QueueStats{
+		Songs:    len(songs),
+		ByArtist: make(map[string]int),
+		ByAlbum:  make(map[string]int),
+	}
+	for i, song := range songs {
+		stats.Total += song.Duration
+		if i > current {
+			stats.Remaining += song.Duration
+		}
+		if artists := song.Tags["Artist"]; len(artists) > 0 {
+			for _, artist := range artists {
+				stats.ByArtist[artist]++
+			}
+		} else {
+			stats.ByArtist[""]++
+		}
+		stats.ByAlbum[song.Tag("Album")]++
+	}
+	return stats
+}
+
+// QueueStats() fetches the queue and the current song position and
+// summarizes them with SummarizeQueue().
+func (conn *Conn) QueueStats() (QueueStats, error) {
+	status, err := conn.Status()
+	if err != nil {
+		return QueueStats{}, err
+	}
+	songs, err := conn.Queue()
+	if err != nil {
+		return QueueStats{}, err
+	}
+	return SummarizeQueue(songs, status.Song), nil
+}
@@ -0,0 +1,186 @@
+package mpd
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrLineBreak is returned for a command argument containing a newline or
+// a carriage return, which would end the command whether quoted or not
+// and let the rest of the argument be run as commands of its own.
+var ErrLineBreak = errors.New("argument contains a line break")
+
+// Quote() quotes a command argument, escaping any backslashes and double
+// quotes it contains, so that arguments such as URIs and playlist names
+// containing spaces or quotes reach the server intact. Arguments can't
+// contain line breaks, for which ErrLineBreak is returned.
+func Quote(arg string) (string, error) {
+	if hasLineBreak(arg) {
+		return "", ErrLineBreak
+	}
+	return quote(arg), nil
+}
+
+// quote() is like Quote(), but doesn't check for line breaks, which are
+// rejected by exec() once the command is sent.
+func quote(arg string) string {
+	var buffer strings.Builder
+	buffer.Grow(len(arg) + 2)
+	buffer.WriteByte('"')
+	for i := 0; i < len(arg); i++ {
+		if arg[i] == '"' || arg[i] == '\\' {
+			buffer.WriteByte('\\')
+		}
+		buffer.WriteByte(arg[i])
+	}
+	buffer.WriteByte('"')
+	return buffer.String()
+}
+
+// FormatCommand() formats a command for Send(), quoting the arguments
+// that need it. Arguments made only of letters, digits and the characters
+// found in numbers and ranges are sent as they are, so
+//
+//	FormatCommand("sticker", "get", "song", uri, "rating")
+//
+// yields
+//
+//	sticker get song "Some Artist/Some Song.flac" rating
+//
+// ErrLineBreak is returned if the name or any of the arguments contains a
+// line break.
+func FormatCommand(name string, args ...string) (string, error) {
+	if hasLineBreak(name) {
+		return "", ErrLineBreak
+	}
+	for _, arg := range args {
+		if hasLineBreak(arg) {
+			return "", ErrLineBreak
+		}
+	}
+	return formatCommand(name, args...), nil
+}
+
+// formatCommand() is like FormatCommand(), but doesn't check for line
+// breaks, which are rejected by exec() once the command is sent.
+func formatCommand(name string, args ...string) string {
+	var buffer strings.Builder
+	buffer.WriteString(name)
+	for _, arg := range args {
+		buffer.WriteByte(' ')
+		if needsQuoting(arg) {
+			buffer.WriteString(quote(arg))
+		} else {
+			buffer.WriteString(arg)
+		}
+	}
+	return buffer.String()
+}
+
+func hasLineBreak(s string) bool {
+	return strings.ContainsAny(s, "\r\n")
+}
+
+// checkLines() makes sure that a command about to be sent is the single
+// command it was meant to be, which an argument with a line break in it
+// would have split into several. Only a command list may span several
+// lines, between its begin and end lines, and no line may leave a quote
+// open, as the quoted argument broken up by a newline would. ErrLineBreak
+// is returned otherwise.
+func checkLines(cmd string) error {
+	if strings.IndexByte(cmd, '\r') >= 0 {
+		return ErrLineBreak
+	}
+	lines := strings.Split(cmd, "\n")
+	if len(lines) > 1 {
+		first, last := lines[0], lines[len(lines)-1]
+		if first != "command_list_begin" && first != "command_list_ok_begin" || last != "command_list_end" {
+			return ErrLineBreak
+		}
+		lines = lines[1 : len(lines)-1]
+		for _, line := range lines {
+			switch line {
+			case "command_list_begin", "command_list_ok_begin", "command_list_end":
+				return ErrLineBreak
+			}
+		}
+	}
+	for _, line := range lines {
+		if !quotesClosed(line) {
+			return ErrLineBreak
+		}
+	}
+	return nil
+}
+
+// quotesClosed() reports whether every quote opened in a line is closed.
+func quotesClosed(line string) bool {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quoted && line[i] == '\\':
+			i++
+		case line[i] == '"':
+			quoted = !quoted
+		}
+	}
+	return !quoted
+}
+
+// needsQuoting() reports whether an argument must be quoted to be sent
+// as a single argument.
+func needsQuoting(arg string) bool {
+	if arg == "" {
+		return true
+	}
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_', c == '-', c == '+', c == '.', c == ':':
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Filter is a filter expression, as taken by find, search and other
+// commands that select songs, such as
+//
+//	((Artist == "Miles Davis") AND (Date >= "1959"))
+//
+// Filters are built with Match(), And() and Not(), which take care of
+// quoting the values within them. Being an argument itself, a filter is
+// quoted again when sent, which FormatCommand() and the methods that take
+// a Filter do.
+type Filter string
+
+// Match() returns a filter comparing a tag, or a special attribute such
+// as "file" or "base", to a value with the given operator, such as "==",
+// "!=", "contains", "starts_with" or "=~".
+func Match(tag, op, value string) Filter {
+	return Filter("(" + tag + " " + op + " " + quote(value) + ")")
+}
+
+// And() returns a filter matching songs that match every one of filters.
+func And(filters ...Filter) Filter {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	parts := make([]string, len(filters))
+	for i, f := range filters {
+		parts[i] = string(f)
+	}
+	return Filter("(" + strings.Join(parts, " AND ") + ")")
+}
+
+// Not() returns a filter matching the songs that f doesn't match.
+func Not(f Filter) Filter {
+	return Filter("(!" + string(f) + ")")
+}
+
+// String() returns the filter expression.
+func (f Filter) String() string {
+	return string(f)
+}
@@ -0,0 +1,157 @@
+package mpd_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{"", `""`},
+		{"plain", `"plain"`},
+		{"with space", `"with space"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+		{`\"`, `"\\\""`},
+	}
+	for _, test := range tests {
+		got, err := mpd.Quote(test.arg)
+		if err != nil || got != test.want {
+			t.Errorf("Quote(%q) = %s, %v, want %s", test.arg, got, err, test.want)
+		}
+	}
+}
+
+func TestQuoteLineBreak(t *testing.T) {
+	for _, arg := range []string{"a\nb", "a\rb", "\n", "song.flac\nclear"} {
+		if _, err := mpd.Quote(arg); !errors.Is(err, mpd.ErrLineBreak) {
+			t.Errorf("Quote(%q) returned %v, want ErrLineBreak", arg, err)
+		}
+	}
+}
+
+func TestFormatCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"status", nil, "status"},
+		{"play", []string{"5"}, "play 5"},
+		{"delete", []string{"3:"}, "delete 3:"},
+		{"seekcur", []string{"+1.500"}, "seekcur +1.500"},
+		{"add", []string{"Artist/Some Song.flac"}, `add "Artist/Some Song.flac"`},
+		{"load", []string{""}, `load ""`},
+		{"find", []string{`(Artist == "AC/DC")`}, `find "(Artist == \"AC/DC\")"`},
+	}
+	for _, test := range tests {
+		got, err := mpd.FormatCommand(test.name, test.args...)
+		if err != nil || got != test.want {
+			t.Errorf("FormatCommand(%q, %q) = %s, %v, want %s", test.name, test.args, got, err, test.want)
+		}
+	}
+}
+
+func TestFormatCommandLineBreak(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"add", []string{"a.flac\nclear"}},
+		{"add", []string{"a.flac\r"}},
+		{"sticker", []string{"set", "song", "a.flac", "rating", "5\nclear"}},
+		{"status\nclear", nil},
+	}
+	for _, test := range tests {
+		if _, err := mpd.FormatCommand(test.name, test.args...); !errors.Is(err, mpd.ErrLineBreak) {
+			t.Errorf("FormatCommand(%q, %q) returned %v, want ErrLineBreak", test.name, test.args, err)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"status", []string{"status"}},
+		{"  play \t 5 ", []string{"play", "5"}},
+		{`add "a b.flac"`, []string{"add", "a b.flac"}},
+		{`load ""`, []string{"load", ""}},
+		{`find "(Artist == \"AC/DC\")"`, []string{"find", `(Artist == "AC/DC")`}},
+		{`rm "back\\slash"`, []string{"rm", `back\slash`}},
+	}
+	for _, test := range tests {
+		if got := mpd.SplitArgs(test.line); !slices.Equal(got, test.want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestFormatCommandRoundTrip(t *testing.T) {
+	args := []string{"", "plain", "with space", `quote"d`, `back\slash`, `\"`, "ünïcödé", "a\tb"}
+	cmd, err := mpd.FormatCommand("cmd", args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{"cmd"}, args...)
+	if got := mpd.SplitArgs(cmd); !slices.Equal(got, want) {
+		t.Errorf("SplitArgs(%s) = %q, want %q", cmd, got, want)
+	}
+}
+
+// TestLineBreakNotSent checks that commands an argument with a line break
+// would have split up are rejected before anything reaches the server.
+func TestLineBreakNotSent(t *testing.T) {
+	srv, err := testutil.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	srv.Handle("clear", func(args []string) ([]string, error) { return nil, nil })
+	srv.Handle("add", func(args []string) ([]string, error) { return nil, nil })
+	conn, err := mpd.ConnectWithOptions(srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sends := []string{
+		"add \"a.flac\nclear\"",
+		"add a.flac\nclear",
+		"add a.flac\r",
+		"command_list_begin\nadd \"a.flac\ncommand_list_end\nclear\"\ncommand_list_end",
+		"command_list_begin\nadd a.flac\ncommand_list_end\nclear\ncommand_list_begin\ncommand_list_end",
+	}
+	for _, cmd := range sends {
+		if _, err := conn.Send(cmd); !errors.Is(err, mpd.ErrLineBreak) {
+			t.Errorf("Send(%q) returned %v, want ErrLineBreak", cmd, err)
+		}
+	}
+	if _, _, err := conn.SendListOK([]string{"add \"a.flac\nclear\""}); !errors.Is(err, mpd.ErrLineBreak) {
+		t.Errorf("SendListOK() returned %v, want ErrLineBreak", err)
+	}
+	cl := conn.NewCommandList()
+	cl.Command("add", "a.flac\nclear")
+	if err := cl.Execute(); !errors.Is(err, mpd.ErrLineBreak) {
+		t.Errorf("Execute() returned %v, want ErrLineBreak", err)
+	}
+	if err := conn.Add("a.flac\nclear"); !errors.Is(err, mpd.ErrLineBreak) {
+		t.Errorf("Add() returned %v, want ErrLineBreak", err)
+	}
+	if got := srv.Received(); len(got) > 0 {
+		t.Errorf("server received %q", got)
+	}
+
+	// A command list of well-formed commands still goes through.
+	if _, err := conn.SendList([]string{`add "a b.flac"`, "clear"}); err != nil {
+		t.Errorf("SendList() returned %v", err)
+	}
+}
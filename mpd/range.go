@@ -0,0 +1,143 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrInvalidRange is returned, wrapped, for a Range that can't be sent to
+// the server.
+var ErrInvalidRange = errors.New("invalid range")
+
+// Range is a range of positions in the queue or in a stored playlist, from
+// Start up to, but not including, End. An End of -1 makes the range
+// open-ended, running to the end.
+type Range struct {
+	Start int
+	End   int
+}
+
+// RangeFrom() returns the range from start to the end.
+func RangeFrom(start int) Range {
+	return Range{Start: start, End: -1}
+}
+
+// SingleRange() returns the range holding only the given position.
+func SingleRange(pos int) Range {
+	return Range{Start: pos, End: pos + 1}
+}
+
+// String() renders the range as the server expects it, such as "5:10" or
+// "5:" for an open-ended range.
+func (r Range) String() string {
+	if r.End < 0 {
+		return strconv.Itoa(r.Start) + ":"
+	}
+	return strconv.Itoa(r.Start) + ":" + strconv.Itoa(r.End)
+}
+
+// Validate() checks that the range is one the server would accept.
+func (r Range) Validate() error {
+	switch {
+	case r.Start < 0:
+		return fmt.Errorf("%w %s: negative start", ErrInvalidRange, r)
+	case r.End < -1:
+		return fmt.Errorf("%w %d:%d: negative end", ErrInvalidRange, r.Start, r.End)
+	case r.End >= 0 && r.End < r.Start:
+		return fmt.Errorf("%w %s: end before start", ErrInvalidRange, r)
+	}
+	return nil
+}
+
+// rangeCommand() formats a command whose first argument is a range, after
+// checking the range.
+func rangeCommand(name string, r Range, args ...string) (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	return formatCommand(name, append([]string{r.String()}, args...)...), nil
+}
+
+// Delete() removes the songs in the given range from the queue.
+func (conn *Conn) Delete(r Range) error {
+	cmd, err := rangeCommand("delete", r)
+	if err != nil {
+		return err
+	}
+	_, err = conn.exec(cmd)
+	return err
+}
+
+// Move() moves the songs in the given range so that the first of them
+// ends up at position to.
+func (conn *Conn) Move(r Range, to int) error {
+	cmd, err := rangeCommand("move", r, strconv.Itoa(to))
+	if err != nil {
+		return err
+	}
+	_, err = conn.exec(cmd)
+	return err
+}
+
+// Shuffle() shuffles the songs in the given range of the queue. Use
+// RangeFrom(0) to shuffle all of them.
+func (conn *Conn) Shuffle(r Range) error {
+	cmd := "shuffle"
+	if r != RangeFrom(0) {
+		var err error
+		if cmd, err = rangeCommand("shuffle", r); err != nil {
+			return err
+		}
+	}
+	_, err := conn.exec(cmd)
+	return err
+}
+
+// Load() adds the songs in the given range of a stored playlist to the end
+// of the queue. Use RangeFrom(0) to add all of them.
+func (conn *Conn) Load(name string, r Range) error {
+	cmd := formatCommand("load", name)
+	if r != RangeFrom(0) {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		cmd = formatCommand("load", name, r.String())
+	}
+	_, err := conn.exec(cmd)
+	return err
+}
+
+// PlaylistInfo() fetches the songs in the given range of the queue.
+func (conn *Conn) PlaylistInfo(r Range) ([]Song, error) {
+	cmd, err := rangeCommand("playlistinfo", r)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := conn.listing(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseSongs(lines), nil
+}
+
+// SetSongRange() makes the song with the given id in the queue play only
+// the given part of its file, as with CUE sheets; a zero range plays all
+// of it again. The song must not be playing. It requires MPD 0.19.
+func (conn *Conn) SetSongRange(id SongID, r TimeRange) error {
+	switch {
+	case r.Start < 0 || r.End < 0:
+		return fmt.Errorf("%w: negative time", ErrInvalidRange)
+	case r.End != 0 && r.End <= r.Start:
+		return fmt.Errorf("%w: end before start", ErrInvalidRange)
+	}
+	arg := ":"
+	if !r.IsZero() {
+		arg = formatSeconds(r.Start) + ":"
+		if r.End != 0 {
+			arg += formatSeconds(r.End)
+		}
+	}
+	_, err := conn.exec(formatCommand("rangeid", strconv.Itoa(int(id)), arg))
+	return err
+}
@@ -0,0 +1,95 @@
+package mpd_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestRangeValidate(t *testing.T) {
+	tests := []struct {
+		r     mpd.Range
+		str   string
+		valid bool
+	}{
+		{mpd.Range{Start: 0, End: 5}, "0:5", true},
+		{mpd.Range{Start: 3, End: 3}, "3:3", true},
+		{mpd.RangeFrom(0), "0:", true},
+		{mpd.RangeFrom(7), "7:", true},
+		{mpd.SingleRange(4), "4:5", true},
+		{mpd.Range{Start: -1, End: 5}, "-1:5", false},
+		{mpd.RangeFrom(-2), "-2:", false},
+		{mpd.Range{Start: 0, End: -2}, "0:", false},
+		{mpd.Range{Start: 5, End: 4}, "5:4", false},
+	}
+	for _, test := range tests {
+		if got := test.r.String(); got != test.str {
+			t.Errorf("%+v.String() = %q, want %q", test.r, got, test.str)
+		}
+		err := test.r.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%+v.Validate() = %v, want nil", test.r, err)
+		}
+		if !test.valid && !errors.Is(err, mpd.ErrInvalidRange) {
+			t.Errorf("%+v.Validate() = %v, want %v", test.r, err, mpd.ErrInvalidRange)
+		}
+	}
+}
+
+func TestRangeCommands(t *testing.T) {
+	srv := startServer(t)
+	for _, name := range []string{"delete", "move", "shuffle", "load", "playlistinfo", "rangeid"} {
+		srv.Handle(name, respond())
+	}
+	conn := connect(t, srv)
+
+	bad := mpd.Range{Start: 5, End: 2}
+	for name, err := range map[string]error{
+		"Delete":       conn.Delete(bad),
+		"Move":         conn.Move(bad, 0),
+		"Shuffle":      conn.Shuffle(bad),
+		"Load":         conn.Load("mix", bad),
+		"SetSongRange": conn.SetSongRange(1, mpd.TimeRange{Start: 10 * time.Second, End: 5 * time.Second}),
+	} {
+		if !errors.Is(err, mpd.ErrInvalidRange) {
+			t.Errorf("%s() with an invalid range = %v, want %v", name, err, mpd.ErrInvalidRange)
+		}
+	}
+	if _, err := conn.PlaylistInfo(bad); !errors.Is(err, mpd.ErrInvalidRange) {
+		t.Errorf("PlaylistInfo() with an invalid range = %v, want %v", err, mpd.ErrInvalidRange)
+	}
+	if got := srv.Received(); len(got) != 0 {
+		t.Fatalf("invalid ranges were sent: %q", got)
+	}
+
+	for _, err := range []error{
+		conn.Delete(mpd.Range{Start: 1, End: 3}),
+		conn.Move(mpd.RangeFrom(4), 0),
+		conn.Shuffle(mpd.RangeFrom(0)),
+		conn.Shuffle(mpd.SingleRange(2)),
+		conn.Load("mix", mpd.RangeFrom(0)),
+		conn.Load("mix", mpd.Range{Start: 0, End: 10}),
+		conn.SetSongRange(1, mpd.TimeRange{Start: 1500 * time.Millisecond}),
+		conn.SetSongRange(1, mpd.TimeRange{}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"delete 1:3",
+		"move 4: 0",
+		"shuffle",
+		"shuffle 2:3",
+		"load mix",
+		"load mix 0:10",
+		"rangeid 1 1.500:",
+		"rangeid 1 :",
+	}
+	if got := srv.Received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
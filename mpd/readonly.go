@@ -0,0 +1,67 @@
+package mpd
+
+import (
+	"fmt"
+)
+
+// ReadOnlyError is returned for commands that would change the server's
+// state when the connection has been made read-only.
+type ReadOnlyError struct {
+	Command string // name of the rejected command
+}
+
+func (err *ReadOnlyError) Error() string {
+	return fmt.Sprintf("command '%s' is not allowed on a read-only connection", err.Command)
+}
+
+// mutatingCommands holds the commands that change the server's state.
+var mutatingCommands = map[string]bool{
+	// queue
+	"add": true, "addid": true, "addtagid": true, "cleartagid": true,
+	"clear": true, "delete": true, "deleteid": true, "move": true,
+	"moveid": true, "prio": true, "prioid": true, "rangeid": true,
+	"shuffle": true, "swap": true, "swapid": true,
+
+	// stored playlists
+	"load": true, "playlistadd": true, "playlistclear": true,
+	"playlistdelete": true, "playlistmove": true, "rename": true,
+	"rm": true, "save": true,
+
+	// database queries that add their results to the queue or a playlist
+	"findadd": true, "searchadd": true, "searchaddpl": true,
+
+	// playback and options
+	"consume": true, "crossfade": true, "mixrampdb": true,
+	"mixrampdelay": true, "random": true, "repeat": true,
+	"replay_gain_mode": true, "setvol": true, "volume": true,
+	"single": true, "next": true, "pause": true, "play": true,
+	"playid": true, "previous": true, "seek": true, "seekid": true,
+	"seekcur": true, "stop": true, "clearerror": true,
+
+	// outputs and partitions
+	"disableoutput": true, "enableoutput": true, "toggleoutput": true,
+	"outputset": true, "moveoutput": true, "newpartition": true,
+	"delpartition": true,
+
+	// database and server
+	"update": true, "rescan": true, "mount": true, "unmount": true,
+	"sendmessage": true, "kill": true,
+}
+
+// isMutating() reports whether a command changes the server's state.
+func isMutating(name string, args []string) bool {
+	if name == "sticker" {
+		return len(args) > 0 && args[0] != "get" && args[0] != "list" && args[0] != "find"
+	}
+	return mutatingCommands[name]
+}
+
+// SetReadOnly() makes the connection reject every command that would
+// change the server's state, such as modifying the queue, playlists,
+// playback options or outputs, with a *ReadOnlyError. The check is made
+// before anything is sent, and applies to Send() and SendList() as well.
+func (conn *Conn) SetReadOnly(readOnly bool) {
+	conn.acquire()
+	defer conn.lock.Unlock()
+	conn.readOnly = readOnly
+}
@@ -0,0 +1,68 @@
+package mpd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		mutating bool
+	}{
+		{"status", false},
+		{"playlistinfo", false},
+		{`find "(Artist == \"X\")"`, false},
+		{`sticker get song "a.flac" rating`, false},
+		{`sticker find song "" rating`, false},
+		{`sticker set song "a.flac" rating 5`, true},
+		{`sticker delete song "a.flac" rating`, true},
+		{`add "a.flac"`, true},
+		{"clear", true},
+		{`findadd "(Artist == \"X\")"`, true},
+		{`searchaddpl mix "(Artist == \"X\")"`, true},
+		{"pause", true},
+		{"setvol 50", true},
+		{`save mix`, true},
+		{"enableoutput 1", true},
+		{"update", true},
+	}
+	srv := startServer(t)
+	for _, name := range []string{"status", "playlistinfo", "find", "sticker", "add", "clear", "findadd", "searchaddpl", "pause", "setvol", "save", "enableoutput", "update"} {
+		srv.Handle(name, respond())
+	}
+	conn := connect(t, srv)
+	conn.SetReadOnly(true)
+	for _, test := range tests {
+		_, err := conn.Send(test.cmd)
+		var readOnlyErr *mpd.ReadOnlyError
+		if got := errors.As(err, &readOnlyErr); got != test.mutating {
+			t.Errorf("Send(%s) on a read-only connection returned %v", test.cmd, err)
+		} else if !got && err != nil {
+			t.Errorf("Send(%s): %v", test.cmd, err)
+		}
+	}
+	for _, cmd := range srv.Received() {
+		for _, test := range tests {
+			if cmd == test.cmd && test.mutating {
+				t.Errorf("server received %s", cmd)
+			}
+		}
+	}
+
+	// A list with a mutating command in it is rejected as a whole.
+	n := len(srv.Received())
+	if _, err := conn.SendList([]string{"status", "clear"}); !errors.As(err, new(*mpd.ReadOnlyError)) {
+		t.Errorf("SendList() returned %v", err)
+	}
+	if got := srv.Received()[n:]; len(got) > 0 {
+		t.Errorf("server received %q", got)
+	}
+
+	conn.SetReadOnly(false)
+	if _, err := conn.Send("clear"); err != nil {
+		t.Errorf("Send(clear) after SetReadOnly(false): %v", err)
+	}
+}
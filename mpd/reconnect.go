@@ -0,0 +1,94 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ReconnectPolicy configures automatic reconnection, enabled with
+// WithReconnect().
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of attempts made before giving up on a
+	// command. Zero means no limit.
+	MaxAttempts int
+
+	// InitialBackoff is the delay after the first failed attempt, which
+	// doubles after every further failure up to MaxBackoff. They default
+	// to 250ms and 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Clock is used to wait between attempts. It defaults to SystemClock.
+	Clock Clock
+}
+
+// WithReconnect() makes the connection re-establish itself when it is
+// lost. A command that finds the connection broken, whether while sending
+// or beforehand, re-dials with exponential backoff and restores the
+// session: the password given by WithPassword() or sent later is sent
+// again, and so are the binary limit, the selection of tag types and the
+// partition, if they were changed. The command that noticed the failure
+// is then sent again if it is Idempotent(); otherwise its error is
+// returned, since there is no way to know whether the server acted on it,
+// and the restored connection is used from the next command on.
+//
+// Other goroutines' commands wait while reconnecting is in progress. The
+// attempts are reported to OnStateChange() callbacks as StateReconnecting
+// events. A connection closed with Close() is never reconnected.
+func WithReconnect(policy ReconnectPolicy) Option {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 250 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 30 * time.Second
+	}
+	return func(opts *options) {
+		opts.reconnect = &policy
+	}
+}
+
+// canReconnect() reports whether a lost connection should be restored.
+func (conn *Conn) canReconnect() bool {
+	return conn.opts.reconnect != nil && !conn.isClosed()
+}
+
+// reconnect() re-establishes a lost connection, retrying as configured by
+// the reconnect policy until it succeeds, it runs out of attempts, or ctx
+// is done. It must be called with the lock held.
+func (conn *Conn) reconnect(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	policy := conn.opts.reconnect
+	clock := clockOrSystem(policy.Clock)
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if conn.isClosed() {
+			return ErrClosed
+		}
+		conn.setState(ConnEvent{State: StateReconnecting, Attempt: attempt})
+		err := conn.session.connect(ctx)
+		if err == nil {
+			err = conn.restore()
+		}
+		if err == nil {
+			conn.setState(ConnEvent{State: StateConnected})
+			if conn.opts.password != "" || conn.settings.password != "" {
+				conn.setState(ConnEvent{State: StateAuthenticated})
+			}
+			return nil
+		}
+		if errors.Is(err, ErrPassword) || policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			conn.setState(ConnEvent{State: StateDisconnected, Err: err})
+			return err
+		}
+		select {
+		case <-clock.After(backoff):
+		case <-ctx.Done():
+			conn.setState(ConnEvent{State: StateDisconnected, Err: ctx.Err()})
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, policy.MaxBackoff)
+	}
+}
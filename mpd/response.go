@@ -0,0 +1,101 @@
+package mpd
+
+import (
+	"slices"
+	"strings"
+)
+
+// splitPair() splits a response line of the form "key: value".
+func splitPair(line string) (key, value string, ok bool) {
+	i := strings.Index(line, ": ")
+	if i < 0 {
+		return "", "", false
+	}
+	return line[:i], line[i+2:], true
+}
+
+// KV is a key/value pair from a response. Lines that aren't pairs have
+// only a Key.
+type KV struct {
+	Key, Value string
+}
+
+// Response is the response to a command, as a list of key/value pairs in
+// the order they were received. Binary data, as sent for cover art, is
+// the Value of a pair whose Key is "binary".
+type Response []KV
+
+// parseResponse() splits the lines of a response into pairs.
+func parseResponse(lines []string) Response {
+	resp := make(Response, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		key, value, ok := splitPair(lines[i])
+		switch {
+		case ok && key == "binary" && i+1 < len(lines):
+			i++
+			resp = append(resp, KV{key, lines[i]})
+		case ok:
+			resp = append(resp, KV{key, value})
+		default:
+			resp = append(resp, KV{Key: lines[i]})
+		}
+	}
+	return resp
+}
+
+// Get() returns the value of the first pair with the given key, or "" if
+// there is none.
+func (resp Response) Get(key string) string {
+	for _, kv := range resp {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return ""
+}
+
+// Map() collects the pairs into a map. If a key appears more than once,
+// the last value wins.
+func (resp Response) Map() map[string]string {
+	m := make(map[string]string, len(resp))
+	for _, kv := range resp {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+// Records() splits a response listing several entities into one record
+// for each, starting at every pair whose key is one of the given keys.
+// Without keys, records start at "file", "directory" and "playlist", as
+// in database and queue listings. Pairs before the first record are
+// dropped.
+func (resp Response) Records(keys ...string) []Response {
+	if len(keys) == 0 {
+		keys = []string{"file", "directory", "playlist"}
+	}
+	var records []Response
+	for i, kv := range resp {
+		if slices.Contains(keys, kv.Key) {
+			records = append(records, resp[i:i+1:i+1])
+		} else if len(records) > 0 {
+			records[len(records)-1] = append(records[len(records)-1], kv)
+		}
+	}
+	return records
+}
+
+// attrs() sends a command and collects its key/value response into a
+// map. If a key appears more than once, the last value wins.
+func (conn *Conn) attrs(cmd string) (map[string]string, error) {
+	lines, err := conn.exec(cmd)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]string, len(lines))
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok {
+			attrs[key] = value
+		}
+	}
+	return attrs, nil
+}
@@ -0,0 +1,94 @@
+package mpd
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// sessionSettings holds the settings made on a connection that the
+// server forgets when it's lost, as the raw commands that made them, so
+// that they can be replayed after reconnecting.
+type sessionSettings struct {
+	password    string   // the last password accepted
+	binaryLimit string   // the last binarylimit command
+	tagTypes    []string // tagtypes commands since the last reset
+	protocol    []string // protocol commands since the last reset
+	partition   string   // the last partition command
+}
+
+// remember() records the settings made by a command that succeeded. It
+// must be called with the lock held.
+func (s *session) remember(cmd string) {
+	for _, line := range strings.Split(cmd, "\n") {
+		args := splitArgs(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "password":
+			s.settings.password = line
+		case "binarylimit":
+			s.settings.binaryLimit = line
+		case "partition":
+			s.settings.partition = line
+		case "tagtypes":
+			if len(args) < 2 {
+				continue
+			}
+			switch args[1] {
+			case "clear", "all", "reset":
+				// These replace the selection entirely, so what came
+				// before doesn't matter anymore.
+				s.settings.tagTypes = []string{line}
+			case "enable", "disable":
+				s.settings.tagTypes = append(s.settings.tagTypes, line)
+			}
+		case "protocol":
+			if len(args) < 2 {
+				continue
+			}
+			switch args[1] {
+			case "clear", "all":
+				s.settings.protocol = []string{line}
+			case "enable", "disable":
+				s.settings.protocol = append(s.settings.protocol, line)
+			}
+		}
+	}
+}
+
+// restore() replays the remembered settings on a new connection. Settings
+// the server no longer accepts, such as a partition that has since been
+// deleted, are forgotten; an error is only returned if the new connection
+// broke too. It must be called with the lock held.
+func (s *session) restore() error {
+	var cmds []*string
+	if s.settings.password != "" && s.settings.password != "password "+quote(s.opts.password) {
+		cmds = append(cmds, &s.settings.password)
+	}
+	cmds = append(cmds, &s.settings.binaryLimit)
+	for i := range s.settings.tagTypes {
+		cmds = append(cmds, &s.settings.tagTypes[i])
+	}
+	for i := range s.settings.protocol {
+		cmds = append(cmds, &s.settings.protocol[i])
+	}
+	cmds = append(cmds, &s.settings.partition)
+
+	for _, cmd := range cmds {
+		if *cmd == "" {
+			continue
+		}
+		_, broken, err := s.roundTrip(nil, time.Time{}, *cmd)
+		if broken {
+			return err
+		} else if err != nil {
+			*cmd = ""
+		}
+	}
+	empty := func(cmd string) bool { return cmd == "" }
+	s.settings.tagTypes = slices.DeleteFunc(s.settings.tagTypes, empty)
+	s.settings.protocol = slices.DeleteFunc(s.settings.protocol, empty)
+	return nil
+}
@@ -0,0 +1,108 @@
+package mpd_test
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+// reconnected() drops the connection, waits for conn to reconnect and
+// returns the commands the server received since the given count.
+func reconnected(t *testing.T, srv *testutil.Server, conn *mpd.Conn, since int) []string {
+	t.Helper()
+	srv.DropConnections()
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Ping() after dropping the connection: %v", err)
+	}
+	return srv.Received()[since:]
+}
+
+func TestRestoreAfterReconnect(t *testing.T) {
+	srv := startServer(t)
+	for _, name := range []string{"password", "binarylimit", "tagtypes", "partition"} {
+		srv.Handle(name, respond())
+	}
+	conn := connect(t, srv, mpd.WithReconnect(mpd.ReconnectPolicy{InitialBackoff: time.Millisecond}))
+
+	settings := []string{
+		`password "secret"`,
+		"binarylimit 8192",
+		"tagtypes enable Genre",
+		"tagtypes clear",
+		"tagtypes enable Artist",
+		"tagtypes enable Title",
+		`partition "kitchen"`,
+	}
+	for _, cmd := range settings {
+		if _, err := conn.Send(cmd); err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+	}
+
+	// Only the tag types since the last clear need to be replayed.
+	want := []string{
+		`password "secret"`,
+		"binarylimit 8192",
+		"tagtypes clear",
+		"tagtypes enable Artist",
+		"tagtypes enable Title",
+		`partition "kitchen"`,
+	}
+	if got := reconnected(t, srv, conn, len(srv.Received())); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+}
+
+func TestRestoreForgetsRejected(t *testing.T) {
+	srv := startServer(t)
+	srv.Handle("binarylimit", respond())
+	var deleted atomic.Bool
+	srv.Handle("partition", func(args []string) ([]string, error) {
+		if deleted.Load() {
+			return nil, &testutil.Ack{Code: 50, Message: "partition does not exist"}
+		}
+		return nil, nil
+	})
+	conn := connect(t, srv, mpd.WithReconnect(mpd.ReconnectPolicy{InitialBackoff: time.Millisecond}))
+	for _, cmd := range []string{"binarylimit 8192", `partition "kitchen"`} {
+		if _, err := conn.Send(cmd); err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+	}
+
+	// The partition is gone by the time the connection is back, which
+	// doesn't keep the connection from working.
+	deleted.Store(true)
+	want := []string{"binarylimit 8192", `partition "kitchen"`}
+	if got := reconnected(t, srv, conn, len(srv.Received())); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+
+	// Once rejected, it isn't tried again.
+	want = []string{"binarylimit 8192"}
+	if got := reconnected(t, srv, conn, len(srv.Received())); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q the second time, want %q", got, want)
+	}
+}
+
+func TestRestoreSkipsFailed(t *testing.T) {
+	srv := startServer(t)
+	srv.Handle("tagtypes", respond())
+	conn := connect(t, srv, mpd.WithReconnect(mpd.ReconnectPolicy{InitialBackoff: time.Millisecond}))
+	if _, err := conn.Send("partition \"kitchen\""); err == nil {
+		t.Fatal("partition succeeded without a handler")
+	}
+	if _, err := conn.Send("tagtypes enable Artist"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Settings the server refused in the first place aren't replayed.
+	want := []string{"tagtypes enable Artist"}
+	if got := reconnected(t, srv, conn, len(srv.Received())); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package mpd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PlaybackState is what is needed to pick up playback where it was left,
+// such as after restarting the server or when moving to another one. It
+// can be stored as JSON.
+type PlaybackState struct {
+	State   PlayerState   `json:"state"`
+	File    string        `json:"file,omitempty"` // the current song, if any
+	Pos     int           `json:"pos"`            // its position in the queue, or -1
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// WithResumeState() restores the playback state saved in the given file,
+// if there is one, once connected by ConnectWithOptions(),
+// ConnectContext() or ConnectAny(), and saves the state to it when the
+// connection is closed with Shutdown(). It isn't restored on reconnects,
+// which only lose the connection and not the server's state, and it is
+// ignored by Pool. A song that is no longer in the queue is skipped
+// silently.
+func WithResumeState(path string) Option {
+	return func(opts *options) {
+		opts.resumeState = path
+	}
+}
+
+// SavePlaybackState() captures the current playback state.
+func (conn *Conn) SavePlaybackState() (PlaybackState, error) {
+	status, err := conn.Status()
+	if err != nil {
+		return PlaybackState{}, err
+	}
+	state := PlaybackState{State: status.State, Pos: -1}
+	if status.Song < 0 {
+		return state, nil
+	}
+	song, ok, err := conn.CurrentSong()
+	if err != nil || !ok {
+		return state, err
+	}
+	state.File = song.File
+	state.Pos = song.Pos
+	state.Elapsed = status.Elapsed
+	return state, nil
+}
+
+// RestorePlaybackState() seeks back to the song and time of a saved
+// playback state, playing or pausing as it was. The song is looked for
+// at its old position in the queue first, and anywhere in it otherwise;
+// if it isn't there, an ErrNoExist error is returned. A stopped state
+// is restored by stopping.
+func (conn *Conn) RestorePlaybackState(state PlaybackState) error {
+	if state.State == Stopped || state.File == "" {
+		return conn.Stop()
+	}
+	pos, err := conn.findInQueue(state.File, state.Pos)
+	if err != nil {
+		return err
+	}
+	cmds := []string{"seek " + strconv.Itoa(pos) + " " + formatSeconds(max(state.Elapsed, 0))}
+	if state.State == Paused {
+		cmds = append(cmds, "pause 1")
+	}
+	_, err = conn.exec(commandList(cmds))
+	return err
+}
+
+// findInQueue() returns the position of a file in the queue, preferring
+// the given position if the file is there.
+func (conn *Conn) findInQueue(file string, pos int) (int, error) {
+	if pos >= 0 {
+		songs, err := conn.PlaylistInfo(SingleRange(pos))
+		if err != nil && !errors.Is(err, ErrArg) {
+			return -1, err
+		}
+		if len(songs) > 0 && songs[0].File == file {
+			return pos, nil
+		}
+	}
+	lines, err := conn.exec(formatCommand("playlistfind", "file", file))
+	if err != nil {
+		return -1, err
+	}
+	if songs := parseSongs(lines); len(songs) > 0 {
+		return songs[0].Pos, nil
+	}
+	return -1, fmt.Errorf("%w: %s is not in the queue", ErrNoExist, file)
+}
+
+// LoadPlaybackState() reads a playback state from a JSON file.
+func LoadPlaybackState(path string) (PlaybackState, error) {
+	var state PlaybackState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// Save() writes the state to a JSON file.
+func (state PlaybackState) Save(path string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// connected() restores the playback state for WithResumeState() on a
+// connection that has just been made, closing it if that fails.
+func (conn *Conn) connected(ctx context.Context) (*Conn, error) {
+	if conn.opts.resumeState == "" {
+		return conn, nil
+	}
+	if err := conn.WithContext(ctx).resume(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// resume() restores the playback state saved by WithResumeState(), if
+// any. Only the connection breaking counts as failure.
+func (conn *Conn) resume() error {
+	state, err := LoadPlaybackState(conn.opts.resumeState)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = conn.RestorePlaybackState(state)
+	if _, ok := AckCode(err); ok || errors.Is(err, ErrNoExist) {
+		return nil
+	}
+	return err
+}
+
+// saveResumeState() saves the playback state for WithResumeState().
+func (conn *Conn) saveResumeState() error {
+	state, err := conn.SavePlaybackState()
+	if err != nil {
+		return err
+	}
+	return state.Save(conn.opts.resumeState)
+}
@@ -0,0 +1,110 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Scheduled is an action set to run on a connection later, started by
+// ScheduleAt() or SleepAfter().
+type Scheduled struct {
+	stop chan struct{}
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// ScheduleAt() runs fn on the connection at the given time, or right away
+// if it has passed, such as to start playback for an alarm clock. It
+// waits on a goroutine of its own, timed by the Clock set with
+// WithClock(), and can be cancelled with Stop() until fn has started.
+func (conn *Conn) ScheduleAt(at time.Time, fn func(conn *Conn) error) *Scheduled {
+	return conn.schedule(at, func(stop <-chan struct{}) error {
+		return fn(conn)
+	})
+}
+
+// schedule() runs an action at the given time. The action is passed a
+// channel that is closed if Stop() is called while it runs.
+func (conn *Conn) schedule(at time.Time, action func(stop <-chan struct{}) error) *Scheduled {
+	s := &Scheduled{stop: make(chan struct{}), done: make(chan struct{})}
+	clock := clockOrSystem(conn.opts.clock)
+	go func() {
+		defer close(s.done)
+		select {
+		case <-s.stop:
+			return
+		case <-clock.After(at.Sub(clock.Now())):
+		}
+		s.err = action(s.stop)
+	}()
+	return s
+}
+
+// Stop() cancels the action if it hasn't started yet, or interrupts it if
+// it can be, and waits for it to finish.
+func (s *Scheduled) Stop() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+// Done() returns a channel that is closed once the action has finished or
+// been cancelled.
+func (s *Scheduled) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err() returns the error returned by the action, if any, once it is
+// done.
+func (s *Scheduled) Err() error {
+	<-s.done
+	return s.err
+}
+
+// SleepAfter() stops playback once d has passed, like the sleep timer of
+// a radio. The volume is faded out over the last fade of that time, as by
+// FadeTo(), and put back once playback has stopped, ready for next time.
+// Without a mixer, playback is stopped without fading. Stop() cancels the
+// timer, putting the volume back if the fade has begun.
+func (conn *Conn) SleepAfter(d, fade time.Duration) (*Scheduled, error) {
+	if d < 0 || fade < 0 {
+		return nil, fmt.Errorf("negative sleep timer duration")
+	}
+	fade = min(fade, d)
+	at := clockOrSystem(conn.opts.clock).Now().Add(d - fade)
+	return conn.schedule(at, func(stop <-chan struct{}) error {
+		return conn.fadeOutAndStop(fade, stop)
+	}), nil
+}
+
+// fadeOutAndStop() fades the volume out over the given duration, stops
+// playback and puts the volume back. If stop is closed first, the volume
+// is put back right away and playback goes on.
+func (conn *Conn) fadeOutAndStop(over time.Duration, stop <-chan struct{}) error {
+	vol, err := conn.Volume()
+	if errors.Is(err, ErrNoMixer) {
+		return conn.Stop()
+	} else if err != nil {
+		return err
+	}
+	fade, err := conn.FadeTo(0, over)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-stop:
+		fade.Stop()
+	case <-fade.Done():
+		if err := fade.Err(); err != nil {
+			return err
+		}
+		if err := conn.Stop(); err != nil {
+			return err
+		}
+	}
+	_, err = conn.exec("setvol " + strconv.Itoa(vol))
+	return err
+}
@@ -0,0 +1,182 @@
+package mpd_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+var epoch = time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+
+func TestScheduleAt(t *testing.T) {
+	srv := startServer(t)
+	clock := testutil.NewClock(epoch)
+	conn := connect(t, srv, mpd.WithClock(clock))
+
+	ran := make(chan time.Time, 1)
+	s := conn.ScheduleAt(epoch.Add(time.Hour), func(*mpd.Conn) error {
+		ran <- clock.Now()
+		return nil
+	})
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour - time.Second)
+	select {
+	case <-ran:
+		t.Fatal("ran early")
+	default:
+	}
+	clock.Advance(time.Second)
+	if at := <-ran; !at.Equal(epoch.Add(time.Hour)) {
+		t.Errorf("ran at %v", at)
+	}
+	if err := s.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScheduleAtStop(t *testing.T) {
+	srv := startServer(t)
+	clock := testutil.NewClock(epoch)
+	conn := connect(t, srv, mpd.WithClock(clock))
+
+	s := conn.ScheduleAt(epoch.Add(time.Hour), func(*mpd.Conn) error {
+		t.Error("cancelled action ran")
+		return nil
+	})
+	clock.BlockUntil(1)
+	s.Stop()
+	clock.Advance(2 * time.Hour)
+	<-s.Done()
+}
+
+func TestScheduleAtPast(t *testing.T) {
+	srv := startServer(t)
+	clock := testutil.NewClock(epoch)
+	conn := connect(t, srv, mpd.WithClock(clock))
+
+	s := conn.ScheduleAt(epoch.Add(-time.Hour), func(*mpd.Conn) error { return nil })
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("an action due in the past didn't run")
+	}
+}
+
+// fakeMixer is the mixer and player of a fake server, reporting every
+// volume set and stop on a channel.
+type fakeMixer struct {
+	mu      sync.Mutex
+	volume  int
+	changes chan string // "setvol N" or "stop"
+}
+
+func newFakeMixer(srv *testutil.Server, volume int) *fakeMixer {
+	m := &fakeMixer{volume: volume, changes: make(chan string, 200)}
+	srv.Handle("getvol", func(args []string) ([]string, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return []string{"volume: " + strconv.Itoa(m.volume)}, nil
+	})
+	srv.Handle("setvol", func(args []string) ([]string, error) {
+		m.mu.Lock()
+		m.volume, _ = strconv.Atoi(args[0])
+		m.mu.Unlock()
+		m.changes <- "setvol " + args[0]
+		return nil, nil
+	})
+	srv.Handle("stop", func(args []string) ([]string, error) {
+		m.changes <- "stop"
+		return nil, nil
+	})
+	return m
+}
+
+// next() waits for the next change to the mixer or player.
+func (m *fakeMixer) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case change := <-m.changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+		return ""
+	}
+}
+
+func TestSleepAfter(t *testing.T) {
+	srv := startServer(t)
+	mixer := newFakeMixer(srv, 50)
+	clock := testutil.NewClock(epoch)
+	conn := connect(t, srv, mpd.WithClock(clock))
+
+	s, err := conn.SleepAfter(30*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(29 * time.Minute)
+
+	// The volume goes down a point at a time over the last minute.
+	clock.BlockUntil(1)
+	for vol := 49; vol >= 0; vol-- {
+		clock.Advance(time.Minute / 50)
+		if got, want := mixer.next(t), "setvol "+strconv.Itoa(vol); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	if got := mixer.next(t); got != "stop" {
+		t.Fatalf("got %q after fading out, want stop", got)
+	}
+	if got := mixer.next(t); got != "setvol 50" {
+		t.Fatalf("got %q after stopping, want the volume put back", got)
+	}
+	if err := s.Err(); err != nil {
+		t.Error(err)
+	}
+	if !clock.Now().Equal(epoch.Add(30 * time.Minute)) {
+		t.Errorf("stopped at %v", clock.Now())
+	}
+}
+
+func TestSleepAfterStopDuringFade(t *testing.T) {
+	srv := startServer(t)
+	mixer := newFakeMixer(srv, 50)
+	clock := testutil.NewClock(epoch)
+	conn := connect(t, srv, mpd.WithClock(clock))
+
+	s, err := conn.SleepAfter(10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(9 * time.Minute)
+	clock.BlockUntil(1)
+	for range 10 {
+		clock.Advance(time.Minute / 50)
+		mixer.next(t)
+	}
+	s.Stop()
+	if got := mixer.next(t); got != "setvol 50" {
+		t.Errorf("got %q after cancelling, want the volume put back", got)
+	}
+	select {
+	case change := <-mixer.changes:
+		t.Errorf("got %q after the volume was put back", change)
+	default:
+	}
+}
+
+func TestSleepAfterNegative(t *testing.T) {
+	srv := startServer(t)
+	conn := connect(t, srv)
+	if _, err := conn.SleepAfter(-time.Minute, 0); err == nil {
+		t.Error("SleepAfter() accepted a negative duration")
+	}
+	if _, err := conn.SleepAfter(time.Minute, -time.Second); err == nil {
+		t.Error("SleepAfter() accepted a negative fade")
+	}
+}
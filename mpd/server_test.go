@@ -0,0 +1,82 @@
+package mpd_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+// startServer() starts a fake server that is closed when the test ends.
+func startServer(t *testing.T) *testutil.Server {
+	t.Helper()
+	srv, err := testutil.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// connect() connects to srv with the given options, closing the
+// connection when the test ends.
+func connect(t *testing.T, srv *testutil.Server, opts ...mpd.Option) *mpd.Conn {
+	t.Helper()
+	conn, err := mpd.ConnectWithOptions(srv.Addr(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// respond() returns a handler that always responds with the given lines.
+func respond(lines ...string) testutil.HandlerFunc {
+	return func(args []string) ([]string, error) {
+		return lines, nil
+	}
+}
+
+// startWatcher() starts a watcher on srv, after letting setup configure
+// it, and closes it when the test ends.
+func startWatcher(t *testing.T, srv *testutil.Server, setup func(w *mpd.Watcher)) *mpd.Watcher {
+	t.Helper()
+	w, err := mpd.NewWatcher(srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(w)
+	}
+	w.Start()
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+// nextEvent() waits for the next event from w.
+func nextEvent(t *testing.T, w *mpd.Watcher) mpd.Event {
+	t.Helper()
+	select {
+	case ev := <-w.Events:
+		return ev
+	case err := <-w.Errors:
+		t.Fatalf("watcher error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return nil
+}
+
+// waitReceived() waits until srv has received cmd.
+func waitReceived(t *testing.T, srv *testutil.Server, cmd string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(srv.Received(), cmd) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q", cmd)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
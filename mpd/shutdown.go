@@ -0,0 +1,59 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+)
+
+// Shutdown() closes the connection in an orderly way, for long-running
+// programs that are stopping. New commands are refused with ErrClosed
+// right away, an idle in progress is ended with noidle, and any other
+// command in progress is given until ctx is done to complete before the
+// connection is closed. Commands that were waiting for their turn fail
+// with ErrClosed. If ctx is done first, the connection is closed anyway
+// and the context's error is returned.
+//
+// With WithResumeState(), the playback state is saved first, and an error
+// saving it is returned along with any other.
+func (conn *Conn) Shutdown(ctx context.Context) error {
+	conn.stateLock.Lock()
+	if conn.closed || conn.draining {
+		conn.stateLock.Unlock()
+		return nil
+	}
+	conn.stateLock.Unlock()
+	var saveErr error
+	if conn.opts.resumeState != "" {
+		saveErr = conn.WithContext(ctx).saveResumeState()
+	}
+	return errors.Join(saveErr, conn.shutdown(ctx))
+}
+
+func (conn *Conn) shutdown(ctx context.Context) error {
+	conn.stateLock.Lock()
+	if conn.closed || conn.draining {
+		conn.stateLock.Unlock()
+		return nil
+	}
+	conn.draining = true
+	conn.stateLock.Unlock()
+
+	conn.interruptIdle()
+	acquired := make(chan struct{})
+	go func() {
+		conn.lock.Lock()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		conn.lock.Unlock()
+		return conn.Close()
+	case <-ctx.Done():
+		go func() {
+			<-acquired
+			conn.lock.Unlock()
+		}()
+		conn.Close()
+		return ctx.Err()
+	}
+}
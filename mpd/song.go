@@ -0,0 +1,240 @@
+package mpd
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SongID is the id of a song in the queue, which unlike its position
+// stays the same as the queue is modified.
+type SongID int
+
+// Song represents a single song, either in the queue or in the database.
+type Song struct {
+	File         string
+	Pos          int    // position in the queue, or -1 if not queued
+	ID           SongID // queue id, or -1 if not queued
+	Duration     time.Duration
+	LastModified time.Time   // modification time of the file, if known
+	Format       AudioFormat // audio format of the file, if known
+	Range        TimeRange   // the part of the file that makes up the song
+
+	// Tags holds every tag reported for the song. Tags that may appear
+	// more than once, such as Artist or Genre, keep all of their values
+	// in the order they were sent.
+	Tags map[string][]string
+}
+
+// TimeRange is a part of a file, such as a track of a CUE sheet.
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration // zero if the song plays to the end of the file
+}
+
+// IsZero() reports whether the range covers the whole file.
+func (r TimeRange) IsZero() bool {
+	return r.Start == 0 && r.End == 0
+}
+
+// parseTimeRange() parses a range such as "10.000-20.500" or "10.000-".
+func parseTimeRange(s string) TimeRange {
+	start, end, _ := strings.Cut(s, "-")
+	return TimeRange{Start: parseFloatSeconds(start), End: parseFloatSeconds(end)}
+}
+
+// Tag() returns the first value of the given tag, or the empty string.
+func (song *Song) Tag(name string) string {
+	if values := song.Tags[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// parseSongs() splits a response into songs, starting a new song at each
+// "file" key. Directory and playlist entries, and any lines belonging to
+// them, are skipped.
+func parseSongs(lines []string) []Song {
+	var songs []Song
+	var p songParser
+	for _, line := range lines {
+		if song, ok := p.parse(line); ok {
+			songs = append(songs, song)
+		}
+	}
+	if song, ok := p.flush(); ok {
+		songs = append(songs, song)
+	}
+	return songs
+}
+
+// songParser parses songs one line at a time, for responses that are
+// read as a stream.
+type songParser struct {
+	song   Song
+	inSong bool // true while reading the lines of a song
+}
+
+// parse() parses a line, returning the previous song once a line starts
+// another entry.
+func (p *songParser) parse(line string) (Song, bool) {
+	key, value, ok := splitPair(line)
+	if !ok {
+		return Song{}, false
+	}
+	if key == "file" || key == "directory" || key == "playlist" {
+		done, ok := p.flush()
+		if key == "file" {
+			p.song = Song{File: value, Pos: -1, ID: -1, Tags: make(map[string][]string)}
+			p.inSong = true
+		}
+		return done, ok
+	}
+	if !p.inSong {
+		return Song{}, false
+	}
+	song := &p.song
+	switch key {
+	case "Pos":
+		song.Pos, _ = strconv.Atoi(value)
+	case "Id":
+		id, _ := strconv.Atoi(value)
+		song.ID = SongID(id)
+	case "duration":
+		song.Duration = parseFloatSeconds(value)
+	case "Last-Modified":
+		song.LastModified, _ = time.Parse(time.RFC3339, value)
+	case "Format":
+		song.Format, _ = ParseAudioFormat(value)
+	case "Range":
+		song.Range = parseTimeRange(value)
+	case "Time":
+		// Older servers only send the rounded duration.
+		if song.Duration == 0 {
+			song.Duration = parseSeconds(value)
+		}
+	default:
+		song.Tags[key] = append(song.Tags[key], value)
+	}
+	return Song{}, false
+}
+
+// flush() returns the song being parsed, if any, at the end of the
+// response.
+func (p *songParser) flush() (Song, bool) {
+	song, ok := p.song, p.inSong
+	p.song, p.inSong = Song{}, false
+	return song, ok
+}
+
+// parseFloatSeconds() parses a fractional number of seconds into a
+// Duration, returning zero if the value is missing or malformed.
+func parseFloatSeconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
+
+// parseFloat() parses a floating point value, returning zero if it is
+// missing or malformed.
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// Queue() fetches every song in the queue.
+func (conn *Conn) Queue() ([]Song, error) {
+	lines, err := conn.listing("playlistinfo")
+	if err != nil {
+		return nil, err
+	}
+	return parseSongs(lines), nil
+}
+
+// ListAllInfo() fetches every song in the database. This can take a while
+// with a large library.
+func (conn *Conn) ListAllInfo() ([]Song, error) {
+	lines, err := conn.listing("listallinfo")
+	if err != nil {
+		return nil, err
+	}
+	return parseSongs(lines), nil
+}
+
+// QueueSeq() is like Queue(), but yields the songs as they are read rather
+// than collecting them first. See ListAllInfoSeq() for how to use it.
+func (conn *Conn) QueueSeq() iter.Seq2[Song, error] {
+	return conn.songSeq("playlistinfo")
+}
+
+// ListAllInfoSeq() is like ListAllInfo(), but yields the songs as they are
+// read rather than collecting them first, so that large libraries can be
+// processed without holding all of them in memory:
+//
+//	for song, err := range conn.ListAllInfoSeq() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// An error ends the sequence. The connection is busy until the sequence
+// ends, so the loop mustn't use it, and should be quick about each song
+// so as not to hit the read timeout. Breaking out of the loop early still
+// reads the rest of the response, discarding it.
+func (conn *Conn) ListAllInfoSeq() iter.Seq2[Song, error] {
+	return conn.songSeq("listallinfo")
+}
+
+// FindSeq() yields the songs in the database matching a filter, such as
+// Match("Artist", "==", "Miles Davis"), as they are read. See
+// ListAllInfoSeq() for how to use it.
+func (conn *Conn) FindSeq(filter Filter) iter.Seq2[Song, error] {
+	return conn.songSeq(formatCommand("find", string(filter)))
+}
+
+// SearchSeq() is like FindSeq(), but compares tags case-insensitively.
+func (conn *Conn) SearchSeq(filter Filter) iter.Seq2[Song, error] {
+	return conn.songSeq(formatCommand("search", string(filter)))
+}
+
+// songSeq() sends a listing command, yielding the songs in its response
+// as they are read.
+func (conn *Conn) songSeq(cmd string) iter.Seq2[Song, error] {
+	return func(yield func(Song, error) bool) {
+		var p songParser
+		stopped := false
+		err := conn.listingEach(cmd, func(line string) {
+			if song, ok := p.parse(line); ok && !stopped {
+				stopped = !yield(song, nil)
+			}
+		})
+		if stopped {
+			return
+		}
+		if err != nil {
+			yield(Song{}, err)
+			return
+		}
+		if song, ok := p.flush(); ok {
+			yield(song, nil)
+		}
+	}
+}
+
+// CurrentSong() fetches the song that is playing or paused. It returns
+// false if there is none.
+func (conn *Conn) CurrentSong() (Song, bool, error) {
+	lines, err := conn.exec("currentsong")
+	if err != nil {
+		return Song{}, false, err
+	}
+	songs := parseSongs(lines)
+	if len(songs) == 0 {
+		return Song{}, false, nil
+	}
+	return songs[0], true, nil
+}
@@ -0,0 +1,66 @@
+// Package sshdial connects to MPD through an SSH connection, for
+// controlling a headless music server remotely without forwarding ports
+// by hand.
+//
+// It works with the clients of golang.org/x/crypto/ssh, without depending
+// on that module itself:
+//
+//	client, err := ssh.Dial("tcp", "musicbox:22", config)
+//	if err != nil {
+//		...
+//	}
+//	conn, err := mpd.ConnectWithOptions("localhost:6600",
+//		mpd.WithDialer(sshdial.New(client)))
+//
+// The MPD address is resolved on the far side of the tunnel, so
+// "localhost" refers to the SSH server, and a Unix socket path works too
+// where the server supports forwarding to one.
+package sshdial
+
+import (
+	"context"
+	"net"
+)
+
+// Client is the part of *ssh.Client used to open tunneled connections.
+type Client interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// Dialer is an mpd.Dialer that opens connections through an SSH client.
+type Dialer struct {
+	client Client
+}
+
+// New() returns a Dialer that tunnels through client, which is usually an
+// *ssh.Client. The client stays owned by the caller, who must close it
+// once the connections made through it are no longer needed.
+func New(client Client) *Dialer {
+	return &Dialer{client: client}
+}
+
+// DialContext() opens a connection to addr from the SSH server. The SSH
+// client can't be interrupted, so if ctx is done first, DialContext()
+// returns right away and the connection is closed once it is made.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := d.client.Dial(network, addr)
+		done <- result{conn, err}
+	}()
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned for commands sent on a connection that has
+	// been closed.
+	ErrClosed = errors.New("connection closed")
+
+	// ErrBroken is returned for commands sent on a connection that has
+	// been lost and isn't set to reconnect, so a new one must be made.
+	// When the connection breaks during a command, the error returned
+	// wraps both ErrBroken and the cause.
+	ErrBroken = errors.New("connection broken")
+)
+
+// ConnState describes the lifecycle state of a connection.
+type ConnState int
+
+const (
+	StateConnected     ConnState = iota // the handshake has completed
+	StateAuthenticated                  // a password was accepted
+	StateDisconnected                   // the connection was closed or lost
+	StateReconnecting                   // a new connection is being attempted
+)
+
+func (state ConnState) String() string {
+	switch state {
+	case StateConnected:
+		return "connected"
+	case StateAuthenticated:
+		return "authenticated"
+	case StateDisconnected:
+		return "disconnected"
+	case StateReconnecting:
+		return "reconnecting"
+	}
+	return fmt.Sprintf("ConnState(%d)", int(state))
+}
+
+// ConnEvent reports a change in a connection's lifecycle state.
+type ConnEvent struct {
+	State   ConnState
+	Err     error // for StateDisconnected, the error that broke the connection, if any
+	Attempt int   // for StateReconnecting, the number of the attempt, starting at 1
+}
+
+// OnStateChange() registers a callback to be called whenever the
+// connection's lifecycle state changes, so that applications can show
+// connectivity without polling with Ping(). The callback is first called
+// once with the current state, which is StateAuthenticated rather than
+// StateConnected if a password was sent. Callbacks are called one event at a time,
+// in order, on a goroutine of their own, so they may use the connection
+// themselves, but shouldn't block for long.
+func (conn *Conn) OnStateChange(callback func(ConnEvent)) {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	conn.stateCallbacks = append(conn.stateCallbacks, callback)
+	conn.queueEvent(queuedEvent{ev: conn.state, only: callback})
+}
+
+// queuedEvent is an event waiting to be delivered to the callbacks, or
+// to a single one if only is set.
+type queuedEvent struct {
+	ev   ConnEvent
+	only func(ConnEvent)
+}
+
+// currentState() returns the connection's current lifecycle state.
+func (conn *Conn) currentState() ConnState {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	return conn.state.State
+}
+
+// setState() records a new state and notifies the callbacks, unless the
+// connection was already in it.
+func (conn *Conn) setState(ev ConnEvent) {
+	conn.stateLock.Lock()
+	defer conn.stateLock.Unlock()
+	if conn.state.State == ev.State && ev.State != StateReconnecting {
+		return
+	}
+	conn.state = ev
+	if len(conn.stateCallbacks) > 0 {
+		conn.queueEvent(queuedEvent{ev: ev})
+	}
+}
+
+// queueEvent() queues an event for delivery, starting a goroutine to
+// deliver it if one isn't running already. It must be called with
+// stateLock held.
+func (conn *Conn) queueEvent(qe queuedEvent) {
+	conn.stateQueue = append(conn.stateQueue, qe)
+	if !conn.dispatching {
+		conn.dispatching = true
+		go conn.dispatchEvents()
+	}
+}
+
+func (conn *Conn) dispatchEvents() {
+	for {
+		conn.stateLock.Lock()
+		if len(conn.stateQueue) == 0 {
+			conn.dispatching = false
+			conn.stateLock.Unlock()
+			return
+		}
+		qe := conn.stateQueue[0]
+		conn.stateQueue = conn.stateQueue[1:]
+		callbacks := append([]func(ConnEvent){}, conn.stateCallbacks...)
+		conn.stateLock.Unlock()
+
+		if qe.only != nil {
+			qe.only(qe.ev)
+			continue
+		}
+		for _, callback := range callbacks {
+			callback(qe.ev)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package mpd
+
+import (
+	"strconv"
+	"time"
+)
+
+// Stats holds the server statistics reported by the stats command.
+type Stats struct {
+	Artists    int
+	Albums     int
+	Songs      int
+	Uptime     time.Duration // time since the server was started
+	Playtime   time.Duration // time spent playing since the server was started
+	DBPlaytime time.Duration // sum of all song durations in the database
+	DBUpdate   time.Time     // time of the last database update
+}
+
+// Stats() fetches the server statistics. Fields the server doesn't
+// report, such as the database ones when it has no database, are left at
+// their zero values.
+func (conn *Conn) Stats() (Stats, error) {
+	attrs, err := conn.attrs("stats")
+	if err != nil {
+		return Stats{}, err
+	}
+	var stats Stats
+	stats.Artists, _ = strconv.Atoi(attrs["artists"])
+	stats.Albums, _ = strconv.Atoi(attrs["albums"])
+	stats.Songs, _ = strconv.Atoi(attrs["songs"])
+	stats.Uptime = parseSeconds(attrs["uptime"])
+	stats.Playtime = parseSeconds(attrs["playtime"])
+	stats.DBPlaytime = parseSeconds(attrs["db_playtime"])
+	if ts, err := strconv.ParseInt(attrs["db_update"], 10, 64); err == nil {
+		stats.DBUpdate = time.Unix(ts, 0)
+	}
+	return stats, nil
+}
+
+// parseSeconds() parses a whole number of seconds into a Duration,
+// returning zero if the value is missing or malformed.
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// StatsDelta describes how the server statistics changed between two
+// samples.
+type StatsDelta struct {
+	Prev, Cur Stats
+
+	Songs      int           // songs added (positive) or removed (negative)
+	Artists    int           // artists added or removed
+	Albums     int           // albums added or removed
+	Playtime   time.Duration // playback time accumulated since Prev
+	DBPlaytime time.Duration // growth of the database's total duration
+	Updated    bool          // true if the database was updated since Prev
+
+	// Restarted is true if the server's uptime went backwards, which
+	// means it was restarted between the two samples. Playtime is then
+	// the playback time since the restart.
+	Restarted bool
+}
+
+// diffStats() computes the delta between two samples.
+func diffStats(prev, cur Stats) StatsDelta {
+	delta := StatsDelta{
+		Prev:       prev,
+		Cur:        cur,
+		Songs:      cur.Songs - prev.Songs,
+		Artists:    cur.Artists - prev.Artists,
+		Albums:     cur.Albums - prev.Albums,
+		DBPlaytime: cur.DBPlaytime - prev.DBPlaytime,
+		Updated:    !cur.DBUpdate.Equal(prev.DBUpdate),
+		Restarted:  cur.Uptime < prev.Uptime,
+	}
+	if delta.Restarted {
+		delta.Playtime = cur.Playtime
+	} else {
+		delta.Playtime = cur.Playtime - prev.Playtime
+	}
+	return delta
+}
+
+// StatsTracker samples the server statistics and reports how they
+// changed since the previous sample.
+type StatsTracker struct {
+	// Clock is used to time the samples taken by Run(). It defaults to
+	// SystemClock.
+	Clock Clock
+
+	conn *Conn
+	last Stats
+}
+
+// NewStatsTracker() creates a tracker and takes its initial sample.
+func NewStatsTracker(conn *Conn) (*StatsTracker, error) {
+	stats, err := conn.Stats()
+	if err != nil {
+		return nil, err
+	}
+	return &StatsTracker{conn: conn, last: stats}, nil
+}
+
+// Last() returns the most recent sample.
+func (t *StatsTracker) Last() Stats {
+	return t.last
+}
+
+// Sample() fetches the current statistics and returns the delta since
+// the previous sample.
+func (t *StatsTracker) Sample() (StatsDelta, error) {
+	stats, err := t.conn.Stats()
+	if err != nil {
+		return StatsDelta{}, err
+	}
+	delta := diffStats(t.last, stats)
+	t.last = stats
+	return delta, nil
+}
+
+// Run() samples the statistics every interval, calling report with each
+// delta, until done is closed or an error occurs.
+func (t *StatsTracker) Run(interval time.Duration, done <-chan struct{}, report func(StatsDelta)) error {
+	ticker := clockOrSystem(t.Clock).NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C():
+		}
+		delta, err := t.Sample()
+		if err != nil {
+			return err
+		}
+		report(delta)
+	}
+}
+
+// RunOnDatabase() waits for database change events and samples the
+// statistics after each one, calling report with the delta. It blocks
+// until an error occurs, such as the connection being closed, so the
+// tracker's connection should not be shared with other goroutines.
+func (t *StatsTracker) RunOnDatabase(report func(StatsDelta)) error {
+	for {
+		if _, err := t.conn.Idle(DatabaseSubsystem); err != nil {
+			return err
+		}
+		delta, err := t.Sample()
+		if err != nil {
+			return err
+		}
+		report(delta)
+	}
+}
@@ -0,0 +1,151 @@
+package mpd
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status holds the server's current status, as reported by the status
+// command.
+type Status struct {
+	Partition string // name of the partition the connection is using
+	State     PlayerState
+	Volume    int // -1 if there is no mixer
+
+	Repeat       bool
+	Random       bool
+	Single       SingleMode
+	Consume      ConsumeMode
+	Crossfade    time.Duration
+	MixRampDB    float64
+	MixRampDelay time.Duration // negative if mixramp is disabled
+
+	Playlist           int    // version of the queue, incremented on every change
+	PlaylistLength     int    // number of songs in the queue
+	LastLoadedPlaylist string // name of the stored playlist last loaded, since 0.24
+
+	Song       int // position of the current song, or -1
+	SongID     SongID
+	NextSong   int // position of the next song, or -1
+	NextSongID SongID
+	Elapsed    time.Duration
+	Duration   time.Duration
+
+	Bitrate     int         // instantaneous bitrate in kbit/s, or 0 if not playing
+	AudioFormat AudioFormat // format of the audio being played
+
+	UpdatingDB int    // id of the running update job, or 0
+	Error      string // the last player error, until cleared with ClearError()
+
+	// Extra holds any fields this package doesn't know about, such as
+	// ones added by newer servers, keyed as MPD names them.
+	Extra map[string]string
+}
+
+// Status() fetches the server's current status.
+func (conn *Conn) Status() (Status, error) {
+	attrs, err := conn.attrs("status")
+	if err != nil {
+		return Status{}, err
+	}
+	return parseStatus(attrs), nil
+}
+
+// ClearError() clears the error reported in the status, such as one left
+// by a song that failed to decode.
+func (conn *Conn) ClearError() error {
+	_, err := conn.exec("clearerror")
+	return err
+}
+
+// parseStatus() parses the response to the status command. Malformed
+// values are left at their defaults.
+func parseStatus(attrs map[string]string) Status {
+	status := Status{
+		Volume:       -1,
+		Song:         -1,
+		SongID:       -1,
+		NextSong:     -1,
+		NextSongID:   -1,
+		MixRampDelay: -1,
+		Extra:        make(map[string]string),
+	}
+	for key, value := range attrs {
+		switch key {
+		case "partition":
+			status.Partition = value
+		case "state":
+			status.State, _ = ParsePlayerState(value)
+		case "volume":
+			status.Volume = parseInt(value, -1)
+		case "repeat":
+			status.Repeat = value == "1"
+		case "random":
+			status.Random = value == "1"
+		case "single":
+			status.Single, _ = ParseSingleMode(value)
+		case "consume":
+			status.Consume, _ = ParseConsumeMode(value)
+		case "xfade":
+			status.Crossfade = parseFloatSeconds(value)
+		case "mixrampdb":
+			status.MixRampDB = parseFloat(value)
+		case "mixrampdelay":
+			if delay, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(delay) {
+				status.MixRampDelay = time.Duration(delay * float64(time.Second))
+			}
+		case "playlist":
+			status.Playlist = parseInt(value, 0)
+		case "playlistlength":
+			status.PlaylistLength = parseInt(value, 0)
+		case "lastloadedplaylist":
+			status.LastLoadedPlaylist = value
+		case "song":
+			status.Song = parseInt(value, -1)
+		case "songid":
+			status.SongID = SongID(parseInt(value, -1))
+		case "nextsong":
+			status.NextSong = parseInt(value, -1)
+		case "nextsongid":
+			status.NextSongID = SongID(parseInt(value, -1))
+		case "elapsed":
+			status.Elapsed = parseFloatSeconds(value)
+		case "duration":
+			status.Duration = parseFloatSeconds(value)
+		case "time":
+			// Superseded by elapsed and duration, but older servers only
+			// send this.
+		case "bitrate":
+			status.Bitrate = parseInt(value, 0)
+		case "audio":
+			status.AudioFormat, _ = ParseAudioFormat(value)
+		case "updating_db":
+			status.UpdatingDB = parseInt(value, 0)
+		case "error":
+			status.Error = value
+		default:
+			status.Extra[key] = value
+		}
+	}
+	if _, ok := attrs["elapsed"]; !ok {
+		if i := strings.IndexByte(attrs["time"], ':'); i >= 0 {
+			status.Elapsed = parseSeconds(attrs["time"][:i])
+		}
+	}
+	if status.Duration == 0 {
+		status.Duration = songDuration(attrs)
+	}
+	return status
+}
+
+// parseInt() parses an integer, returning def if it is missing or
+// malformed.
+func parseInt(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
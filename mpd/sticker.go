@@ -0,0 +1,235 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// stickerFind() returns the value of the named sticker for every song
+// under the given directory that has it, keyed by song URI.
+func (conn *Conn) stickerFind(dir, name string) (map[string]string, error) {
+	lines, err := conn.exec(formatCommand("sticker", "find", "song", dir, name))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, match := range parseStickerFind(lines, name) {
+		values[match.URI] = match.Value
+	}
+	return values, nil
+}
+
+// StickerMatch is a single result of a sticker search.
+type StickerMatch struct {
+	URI   string
+	Value string
+}
+
+// parseStickerFind() parses the response to sticker find, keeping only
+// stickers with the given name.
+func parseStickerFind(lines []string, name string) []StickerMatch {
+	var matches []StickerMatch
+	var file string
+	for _, line := range lines {
+		key, value, ok := splitPair(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "file":
+			file = value
+		case "sticker":
+			if n, v, ok := splitSticker(value); ok && n == name {
+				matches = append(matches, StickerMatch{URI: file, Value: v})
+			}
+		}
+	}
+	return matches
+}
+
+// stickerSet() sets a sticker on a song.
+func (conn *Conn) stickerSet(uri, name, value string) error {
+	_, err := conn.exec(formatCommand("sticker", "set", "song", uri, name, value))
+	return err
+}
+
+// splitSticker() splits a "name=value" sticker.
+func splitSticker(s string) (name, value string, ok bool) {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// stickerList() returns every sticker set on a song.
+func (conn *Conn) stickerList(uri string) (map[string]string, error) {
+	lines, err := conn.exec(formatCommand("sticker", "list", "song", uri))
+	if err != nil {
+		return nil, err
+	}
+	stickers := make(map[string]string)
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "sticker" {
+			if name, v, ok := splitSticker(value); ok {
+				stickers[name] = v
+			}
+		}
+	}
+	return stickers, nil
+}
+
+// stickerBatchSize is the number of sticker commands sent per command
+// list by the bulk sticker operations.
+const stickerBatchSize = 100
+
+// sendBatched() sends commands in command lists of at most
+// stickerBatchSize commands each, returning the number of commands that
+// were run. If one fails, the commands before it have been run all the
+// same, including those earlier in the same command list.
+func (conn *Conn) sendBatched(cmds []string) (int, error) {
+	done := 0
+	for len(cmds) > 0 {
+		n := min(len(cmds), stickerBatchSize)
+		if _, err := conn.exec(commandList(cmds[:n])); err != nil {
+			var ackErr *AckError
+			if errors.As(err, &ackErr) {
+				done += ackErr.Index
+			}
+			return done, err
+		}
+		done += n
+		cmds = cmds[n:]
+	}
+	return done, nil
+}
+
+// RenameSticker() renames a sticker on every song that has it, returning
+// the number of songs changed. Songs that already have a sticker with
+// the new name have it overwritten. The names must differ.
+//
+// The songs are changed in batches, so the rename isn't atomic: if it
+// fails partway, the songs counted have been renamed already and the
+// others still have the old name, except that the song being renamed
+// when it failed may have both.
+func (conn *Conn) RenameSticker(oldName, newName string) (int, error) {
+	if oldName == newName {
+		return 0, fmt.Errorf("can't rename sticker '%s' to itself", oldName)
+	}
+	values, err := conn.stickerFind("", oldName)
+	if err != nil {
+		return 0, err
+	}
+	cmds := make([]string, 0, 2*len(values))
+	for uri, value := range values {
+		cmds = append(cmds,
+			formatCommand("sticker", "set", "song", uri, newName, value),
+			formatCommand("sticker", "delete", "song", uri, oldName))
+	}
+	done, err := conn.sendBatched(cmds)
+	return done / 2, err
+}
+
+// CopyStickers() copies every sticker set on one song to another, such as
+// after the file was moved and the old URI no longer exists. Stickers
+// already set on the destination are overwritten.
+func (conn *Conn) CopyStickers(from, to string) error {
+	stickers, err := conn.stickerList(from)
+	if err != nil {
+		return err
+	}
+	cmds := make([]string, 0, len(stickers))
+	for name, value := range stickers {
+		cmds = append(cmds, formatCommand("sticker", "set", "song", to, name, value))
+	}
+	_, err = conn.sendBatched(cmds)
+	return err
+}
+
+// DeleteStickers() removes a sticker from every song that has it,
+// returning the number of songs changed. The songs are changed in
+// batches, so this isn't atomic: if it fails partway, the songs counted
+// have lost the sticker already and the others still have it.
+func (conn *Conn) DeleteStickers(name string) (int, error) {
+	values, err := conn.stickerFind("", name)
+	if err != nil {
+		return 0, err
+	}
+	cmds := make([]string, 0, len(values))
+	for uri := range values {
+		cmds = append(cmds, formatCommand("sticker", "delete", "song", uri, name))
+	}
+	return conn.sendBatched(cmds)
+}
+
+// StickerIter pages through the results of a sticker search. It is
+// created by StickerFindIter() and used like a bufio.Scanner:
+//
+//	it := conn.StickerFindIter("", "playcount", 1000)
+//	for it.Next() {
+//		match := it.Match()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type StickerIter struct {
+	conn     *Conn
+	dir      string
+	name     string
+	pageSize int
+
+	page  []StickerMatch
+	index int  // index of the current match in page
+	start int  // window start of the next page to fetch
+	last  bool // true once a short page has been fetched
+	err   error
+}
+
+// StickerFindIter() returns an iterator over every song under the given
+// directory that has the named sticker, fetching pageSize results at a
+// time. Results are sorted by URI so that pages don't overlap. This
+// requires MPD 0.24 or later.
+func (conn *Conn) StickerFindIter(dir, name string, pageSize int) *StickerIter {
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	return &StickerIter{conn: conn, dir: dir, name: name, pageSize: pageSize, index: -1}
+}
+
+// Next() advances to the next match, returning false when there are no
+// more or an error occurred.
+func (it *StickerIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if it.last {
+			return false
+		}
+		window := Range{Start: it.start, End: it.start + it.pageSize}
+		cmd := formatCommand("sticker", "find", "song", it.dir, it.name, "sort", "uri", "window", window.String())
+		lines, err := it.conn.exec(cmd)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = parseStickerFind(lines, it.name)
+		it.index = 0
+		it.start += it.pageSize
+		it.last = len(it.page) < it.pageSize
+	}
+	return true
+}
+
+// Match() returns the current match.
+func (it *StickerIter) Match() StickerMatch {
+	return it.page[it.index]
+}
+
+// Err() returns the error that stopped the iteration, if any.
+func (it *StickerIter) Err() error {
+	return it.err
+}
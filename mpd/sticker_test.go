@@ -0,0 +1,246 @@
+package mpd_test
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+// fakeStickers are the song stickers of a fake server.
+type fakeStickers struct {
+	mu       sync.Mutex
+	stickers map[string]map[string]string // by song, then by name
+	failOn   string                       // a song whose stickers can't be changed
+}
+
+func newFakeStickers(srv *testutil.Server) *fakeStickers {
+	s := &fakeStickers{stickers: make(map[string]map[string]string)}
+	srv.Handle("sticker", s.handle)
+	return s
+}
+
+func (s *fakeStickers) set(uri, name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stickers[uri] == nil {
+		s.stickers[uri] = make(map[string]string)
+	}
+	s.stickers[uri][name] = value
+}
+
+// songsWith() returns the songs that have the named sticker and don't
+// have the other one, if given.
+func (s *fakeStickers) songsWith(name, without string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var uris []string
+	for uri, stickers := range s.stickers {
+		_, has := stickers[name]
+		_, other := stickers[without]
+		if has && (without == "" || !other) {
+			uris = append(uris, uri)
+		}
+	}
+	slices.Sort(uris)
+	return uris
+}
+
+func (s *fakeStickers) handle(args []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(args) < 3 || args[1] != "song" {
+		return nil, &testutil.Ack{Code: 2, Message: "bad sticker command"}
+	}
+	switch uri := args[2]; args[0] {
+	case "set":
+		if uri == s.failOn {
+			return nil, &testutil.Ack{Code: 4, Message: "read-only song"}
+		}
+		if s.stickers[uri] == nil {
+			s.stickers[uri] = make(map[string]string)
+		}
+		s.stickers[uri][args[3]] = args[4]
+	case "delete":
+		if uri == s.failOn {
+			return nil, &testutil.Ack{Code: 4, Message: "read-only song"}
+		}
+		if _, ok := s.stickers[uri][args[3]]; !ok {
+			return nil, &testutil.Ack{Code: 50, Message: "no such sticker"}
+		}
+		delete(s.stickers[uri], args[3])
+	case "list":
+		var lines []string
+		for _, name := range slices.Sorted(maps.Keys(s.stickers[uri])) {
+			lines = append(lines, "sticker: "+name+"="+s.stickers[uri][name])
+		}
+		return lines, nil
+	case "find":
+		return s.find(args[3], args[4:])
+	}
+	return nil, nil
+}
+
+// find() handles a search for a sticker over the whole database, sorted
+// by URI and limited to a window of the results if asked to, as MPD 0.24
+// does.
+func (s *fakeStickers) find(name string, args []string) ([]string, error) {
+	var start, end int
+	window := slices.Index(args, "window")
+	if window >= 0 {
+		if window+1 >= len(args) {
+			return nil, &testutil.Ack{Code: 2, Message: "missing window"}
+		}
+		if _, err := fmt.Sscanf(args[window+1], "%d:%d", &start, &end); err != nil {
+			return nil, &testutil.Ack{Code: 2, Message: "bad window"}
+		}
+	}
+	var lines []string
+	found := 0
+	for _, uri := range slices.Sorted(maps.Keys(s.stickers)) {
+		value, ok := s.stickers[uri][name]
+		if !ok {
+			continue
+		}
+		if window < 0 || start <= found && found < end {
+			lines = append(lines, "file: "+uri, "sticker: "+name+"="+value)
+		}
+		found++
+	}
+	return lines, nil
+}
+
+func TestRenameSticker(t *testing.T) {
+	srv := startServer(t)
+	stickers := newFakeStickers(srv)
+	for i := range 250 {
+		stickers.set(fmt.Sprintf("song-%03d.flac", i), "rating", fmt.Sprint(i%5))
+	}
+	stickers.set("other.flac", "playcount", "3")
+	conn := connect(t, srv)
+
+	n, err := conn.RenameSticker("rating", "stars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 250 {
+		t.Errorf("RenameSticker() = %d, want 250", n)
+	}
+	if got := stickers.songsWith("rating", ""); len(got) > 0 {
+		t.Errorf("songs still rated: %q", got)
+	}
+	if got := stickers.songsWith("stars", ""); len(got) != 250 {
+		t.Errorf("%d songs have stars, want 250", len(got))
+	}
+	if _, err := conn.RenameSticker("stars", "stars"); err == nil {
+		t.Error("RenameSticker() to the same name succeeded")
+	}
+}
+
+// TestRenameStickerPartial checks that a rename failing partway counts
+// the songs renamed before it failed.
+func TestRenameStickerPartial(t *testing.T) {
+	srv := startServer(t)
+	stickers := newFakeStickers(srv)
+	for i := range 250 {
+		stickers.set(fmt.Sprintf("song-%03d.flac", i), "rating", "1")
+	}
+	stickers.failOn = "song-123.flac"
+	conn := connect(t, srv)
+
+	n, err := conn.RenameSticker("rating", "stars")
+	if err == nil {
+		t.Fatal("RenameSticker() succeeded despite a failing song")
+	}
+	if renamed := stickers.songsWith("stars", "rating"); n != len(renamed) {
+		t.Errorf("RenameSticker() = %d, but %d songs were renamed", n, len(renamed))
+	}
+}
+
+func TestDeleteStickersPartial(t *testing.T) {
+	srv := startServer(t)
+	stickers := newFakeStickers(srv)
+	for i := range 250 {
+		stickers.set(fmt.Sprintf("song-%03d.flac", i), "rating", "1")
+	}
+	stickers.failOn = "song-123.flac"
+	conn := connect(t, srv)
+
+	n, err := conn.DeleteStickers("rating")
+	if err == nil {
+		t.Fatal("DeleteStickers() succeeded despite a failing song")
+	}
+	if left := stickers.songsWith("rating", ""); n != 250-len(left) {
+		t.Errorf("DeleteStickers() = %d, but %d songs lost the sticker", n, 250-len(left))
+	}
+}
+
+func TestCopyStickers(t *testing.T) {
+	srv := startServer(t)
+	stickers := newFakeStickers(srv)
+	stickers.set("old.flac", "rating", "5")
+	stickers.set("old.flac", "playcount", "12")
+	stickers.set("new.flac", "rating", "1")
+	conn := connect(t, srv)
+
+	if err := conn.CopyStickers("old.flac", "new.flac"); err != nil {
+		t.Fatal(err)
+	}
+	stickers.mu.Lock()
+	defer stickers.mu.Unlock()
+	if got := stickers.stickers["new.flac"]; !maps.Equal(got, stickers.stickers["old.flac"]) {
+		t.Errorf("new.flac has stickers %v", got)
+	}
+}
+
+func TestStickerFindIter(t *testing.T) {
+	for _, songs := range []int{0, 1, 99, 100, 101, 250, 300} {
+		srv := startServer(t)
+		stickers := newFakeStickers(srv)
+		var want []mpd.StickerMatch
+		for i := range songs {
+			uri := fmt.Sprintf("song-%03d.flac", i)
+			stickers.set(uri, "playcount", fmt.Sprint(i))
+			want = append(want, mpd.StickerMatch{URI: uri, Value: fmt.Sprint(i)})
+		}
+		stickers.set("other.flac", "rating", "5")
+		conn := connect(t, srv)
+
+		var got []mpd.StickerMatch
+		it := conn.StickerFindIter("", "playcount", 100)
+		for it.Next() {
+			got = append(got, it.Match())
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%d songs: %v", songs, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%d songs: got %d matches, want %d", songs, len(got), len(want))
+		}
+		if pages, want := len(srv.Received()), songs/100+1; pages != want {
+			t.Errorf("%d songs: fetched %d pages, want %d", songs, pages, want)
+		}
+	}
+}
+
+func TestStickerFindIterError(t *testing.T) {
+	srv := startServer(t)
+	srv.Handle("sticker", func(args []string) ([]string, error) {
+		return nil, &testutil.Ack{Code: 5, Message: "unknown command"}
+	})
+	conn := connect(t, srv)
+	it := conn.StickerFindIter("", "playcount", 100)
+	if it.Next() {
+		t.Error("Next() returned a match despite the error")
+	}
+	if _, ok := mpd.AckCode(it.Err()); !ok {
+		t.Errorf("Err() = %v, want the ACK", it.Err())
+	}
+	if it.Next() {
+		t.Error("Next() went on after the error")
+	}
+}
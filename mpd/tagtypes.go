@@ -0,0 +1,135 @@
+package mpd
+
+// TagTypes() returns the tag types the server currently reports on this
+// connection.
+func (conn *Conn) TagTypes() ([]string, error) {
+	return conn.tagTypeList("tagtypes")
+}
+
+// AvailableTagTypes() returns every tag type the server knows about,
+// whether or not it is enabled on this connection. It requires MPD 0.24.
+func (conn *Conn) AvailableTagTypes() ([]string, error) {
+	return conn.tagTypeList("tagtypes available")
+}
+
+func (conn *Conn) tagTypeList(cmd string) ([]string, error) {
+	lines, err := conn.exec(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, line := range lines {
+		if key, value, ok := splitPair(line); ok && key == "tagtype" {
+			tags = append(tags, value)
+		}
+	}
+	return tags, nil
+}
+
+// EnableTagTypes() makes the server report the given tag types, in
+// addition to those already enabled. Like the other methods changing tag
+// types, it only affects this connection, and the selection is restored
+// if the connection reconnects.
+func (conn *Conn) EnableTagTypes(tags ...string) error {
+	_, err := conn.exec(formatCommand("tagtypes", append([]string{"enable"}, tags...)...))
+	return err
+}
+
+// DisableTagTypes() stops the server from reporting the given tag types,
+// which shrinks listings for clients that don't show them.
+func (conn *Conn) DisableTagTypes(tags ...string) error {
+	_, err := conn.exec(formatCommand("tagtypes", append([]string{"disable"}, tags...)...))
+	return err
+}
+
+// ClearTagTypes() disables every tag type, leaving only the file names
+// and other non-tag attributes in listings.
+func (conn *Conn) ClearTagTypes() error {
+	_, err := conn.exec("tagtypes clear")
+	return err
+}
+
+// AllTagTypes() enables every tag type again.
+func (conn *Conn) AllTagTypes() error {
+	_, err := conn.exec("tagtypes all")
+	return err
+}
+
+// ResetTagTypes() enables exactly the given tag types in a single command.
+// It requires MPD 0.24; on older servers, ClearTagTypes() followed by
+// EnableTagTypes() does the same.
+func (conn *Conn) ResetTagTypes(tags ...string) error {
+	_, err := conn.exec(formatCommand("tagtypes", append([]string{"reset"}, tags...)...))
+	return err
+}
+
+// setTagTypes() enables exactly the given tag types.
+func (conn *Conn) setTagTypes(tags []string) error {
+	cmds := []string{"tagtypes clear"}
+	if len(tags) > 0 {
+		cmds = append(cmds, formatCommand("tagtypes", append([]string{"enable"}, tags...)...))
+	}
+	_, err := conn.SendList(cmds)
+	return err
+}
+
+// WithTagTypes() narrows the tags the server reports to the given set,
+// calls fn, and then restores the previous set. This can shrink the
+// responses of bulk listings considerably when only a few tags are
+// needed. Other goroutines using the connection while fn runs will see
+// the narrowed set as well.
+func (conn *Conn) WithTagTypes(tags []string, fn func() error) error {
+	prev, err := conn.TagTypes()
+	if err != nil {
+		return err
+	}
+	if err := conn.setTagTypes(tags); err != nil {
+		return err
+	}
+	fnErr := fn()
+	if err := conn.setTagTypes(prev); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
+
+// SetListingTagTypes() sets the tags reported by bulk listings made by
+// this package, such as those behind QueueStats() and FindDuplicates().
+// The connection's tag types are narrowed for the duration of each
+// listing and restored afterwards. Calling it with no tags turns this
+// off again.
+func (conn *Conn) SetListingTagTypes(tags ...string) {
+	conn.acquire()
+	defer conn.lock.Unlock()
+	conn.listingTags = tags
+}
+
+// listing() sends a bulk listing command, applying the tag types set by
+// SetListingTagTypes().
+func (conn *Conn) listing(cmd string) (lines []string, err error) {
+	err = conn.withListingTags(func() error {
+		lines, err = conn.exec(cmd)
+		return err
+	})
+	return lines, err
+}
+
+// listingEach() is like listing(), but passes each line to fn as it is
+// read, as execEach() does.
+func (conn *Conn) listingEach(cmd string, fn func(line string)) error {
+	return conn.withListingTags(func() error {
+		return conn.execEach(cmd, fn)
+	})
+}
+
+// withListingTags() runs fn with the tag types set by
+// SetListingTagTypes(), if any.
+func (conn *Conn) withListingTags(fn func() error) error {
+	conn.acquire()
+	tags := conn.listingTags
+	conn.lock.Unlock()
+	if len(tags) == 0 {
+		return fn()
+	}
+	return conn.WithTagTypes(tags, fn)
+}
@@ -0,0 +1,152 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// Clock is an mpd.Clock whose time only moves when Advance() is called,
+// so that the time-based helpers of the mpd package can be tested without
+// real sleeps. It is safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*clockWaiter
+	changed chan struct{} // closed and replaced whenever waiters changes
+}
+
+// clockWaiter is a pending After() or an active ticker.
+type clockWaiter struct {
+	when   time.Time
+	period time.Duration // zero for After()
+	c      chan time.Time
+}
+
+// NewClock() returns a clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now, changed: make(chan struct{})}
+}
+
+// Now() returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After() returns a channel that receives the time once the clock has
+// been advanced by d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &clockWaiter{when: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- c.now
+		return w.c
+	}
+	c.addLocked(w)
+	return w.c
+}
+
+// NewTicker() returns a ticker that ticks every d of the clock's time.
+// Like a time.Ticker, it drops ticks that aren't received in time.
+func (c *Clock) NewTicker(d time.Duration) mpd.Ticker {
+	if d <= 0 {
+		panic("testutil: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &clockWaiter{when: c.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	c.addLocked(w)
+	return &clockTicker{clock: c, w: w}
+}
+
+// Advance() moves the clock forward by d, firing the timers and ticks
+// that fall due in order, each at its own time.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, w := range c.waiters {
+			if !w.when.After(end) && (next < 0 || w.when.Before(c.waiters[next].when)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		w := c.waiters[next]
+		c.now = w.when
+		select {
+		case w.c <- c.now:
+		default:
+		}
+		if w.period > 0 {
+			w.when = w.when.Add(w.period)
+		} else {
+			c.removeLocked(w)
+		}
+	}
+	c.now = end
+}
+
+// Waiters() returns the number of pending timers and active tickers.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil() waits until there are at least n pending timers and
+// active tickers, such as once the code under test is waiting on the
+// clock and is ready for it to be advanced.
+func (c *Clock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		count, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (c *Clock) addLocked(w *clockWaiter) {
+	c.waiters = append(c.waiters, w)
+	c.notifyLocked()
+}
+
+func (c *Clock) removeLocked(w *clockWaiter) {
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.notifyLocked()
+			return
+		}
+	}
+}
+
+func (c *Clock) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+type clockTicker struct {
+	clock *Clock
+	w     *clockWaiter
+}
+
+func (t *clockTicker) C() <-chan time.Time {
+	return t.w.c
+}
+
+func (t *clockTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.removeLocked(t.w)
+}
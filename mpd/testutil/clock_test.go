@@ -0,0 +1,94 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClockAfter(t *testing.T) {
+	clock := testutil.NewClock(epoch)
+	c := clock.After(time.Minute)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-c:
+		t.Fatal("fired early")
+	default:
+	}
+	clock.Advance(2 * time.Second)
+	if at := <-c; !at.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("fired at %v", at)
+	}
+	if got := clock.Now(); !got.Equal(epoch.Add(61 * time.Second)) {
+		t.Errorf("Now() = %v", got)
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("%d waiters left", n)
+	}
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("After(0) didn't fire right away")
+	}
+}
+
+func TestClockTicker(t *testing.T) {
+	clock := testutil.NewClock(epoch)
+	ticker := clock.NewTicker(time.Second)
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Second)
+		if at := <-ticker.C(); !at.Equal(epoch.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("tick %d at %v", i, at)
+		}
+	}
+
+	// Ticks that aren't received are dropped.
+	clock.Advance(10 * time.Second)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(4 * time.Second)) {
+		t.Errorf("kept tick at %v", at)
+	}
+	select {
+	case at := <-ticker.C():
+		t.Errorf("got a second tick at %v", at)
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Second)
+	select {
+	case at := <-ticker.C():
+		t.Errorf("got a tick at %v after Stop()", at)
+	default:
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("%d waiters left", n)
+	}
+}
+
+func TestClockOrder(t *testing.T) {
+	clock := testutil.NewClock(epoch)
+	late, early := clock.After(2*time.Second), clock.After(time.Second)
+	clock.Advance(time.Hour)
+	if at := <-early; !at.Equal(epoch.Add(time.Second)) {
+		t.Errorf("early timer fired at %v", at)
+	}
+	if at := <-late; !at.Equal(epoch.Add(2 * time.Second)) {
+		t.Errorf("late timer fired at %v", at)
+	}
+}
+
+func TestClockBlockUntil(t *testing.T) {
+	clock := testutil.NewClock(epoch)
+	fired := make(chan time.Time)
+	go func() {
+		fired <- <-clock.After(time.Minute)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	if at := <-fired; !at.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("fired at %v", at)
+	}
+}
@@ -0,0 +1,348 @@
+// Package testutil provides a fake MPD server, a stress harness and a
+// manual clock for testing code built on the mpd package, including its
+// thread-safety and its time-based helpers.
+package testutil
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Version is the protocol version the fake server reports unless told
+// otherwise with SetVersion().
+const Version = "0.23.5"
+
+// HandlerFunc handles a command sent to the fake server. It returns the
+// response lines, not including the final OK, or an error, which is
+// reported to the client as an ACK. Errors of type *Ack control the ACK
+// code; any other error is reported as ACK_ERROR_UNKNOWN.
+type HandlerFunc func(args []string) ([]string, error)
+
+// Ack is an error that a HandlerFunc returns to send a specific ACK.
+type Ack struct {
+	Code    int
+	Message string
+}
+
+func (a *Ack) Error() string {
+	return a.Message
+}
+
+// Server is a minimal in-process MPD server. It understands ping, close,
+// idle, noidle and command lists itself, answers "echo" with its
+// arguments as "echo: ARG" lines, and answers everything else with the
+// handlers registered through Handle().
+type Server struct {
+	listener net.Listener
+	commands int64
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	clients  map[*client]bool
+	closed   bool
+	version  string
+	received []string
+}
+
+// NewServer() starts a fake server listening on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		handlers: make(map[string]HandlerFunc),
+		clients:  make(map[*client]bool),
+		version:  Version,
+	}
+	s.Handle("echo", func(args []string) ([]string, error) {
+		lines := make([]string, len(args))
+		for i, arg := range args {
+			lines[i] = "echo: " + arg
+		}
+		return lines, nil
+	})
+	go s.accept()
+	return s, nil
+}
+
+// Addr() returns the address the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Handle() registers the handler for a command, replacing any previous
+// one.
+func (s *Server) Handle(name string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = handler
+}
+
+// SetVersion() sets the protocol version reported to clients that connect
+// from now on.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+}
+
+// Received() returns the commands received so far, other than ping,
+// idle, noidle and close, each command of a command list separately.
+func (s *Server) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// Commands() returns the number of commands received so far, counting
+// each command in a command list separately.
+func (s *Server) Commands() int64 {
+	return atomic.LoadInt64(&s.commands)
+}
+
+// Notify() reports changes to the given subsystems to every connected
+// client, as idle does on a real server.
+func (s *Server) Notify(subsystems ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		c.notify(subsystems)
+	}
+}
+
+// DropConnections() abruptly closes every client connection, simulating
+// a server restart or network failure. New connections are still
+// accepted.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+}
+
+// Close() stops the server and closes every client connection.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	err := s.listener.Close()
+	s.DropConnections()
+	return err
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &client{server: s, conn: conn, out: bufio.NewWriter(conn), pending: make(map[string]bool)}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.clients[c] = true
+		s.mu.Unlock()
+		go c.serve()
+	}
+}
+
+func (s *Server) handler(name string) HandlerFunc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handlers[name]
+}
+
+// client is a single connection to the fake server.
+type client struct {
+	server *Server
+	conn   net.Conn
+
+	mu      sync.Mutex // guards everything below
+	out     *bufio.Writer
+	idling  bool
+	idleFor map[string]bool // subsystems being waited for; empty means all
+	pending map[string]bool // changes not yet reported
+}
+
+func (c *client) serve() {
+	defer func() {
+		c.server.mu.Lock()
+		delete(c.server.clients, c)
+		c.server.mu.Unlock()
+		c.conn.Close()
+	}()
+	c.server.mu.Lock()
+	version := c.server.version
+	c.server.mu.Unlock()
+	c.write("OK MPD " + version)
+	in := bufio.NewScanner(c.conn)
+	var list []string
+	inList, listOK := false, false
+	for in.Scan() {
+		line := in.Text()
+		switch {
+		case inList && line == "command_list_end":
+			c.runList(list, listOK)
+			list, inList = nil, false
+		case inList:
+			list = append(list, line)
+		case line == "command_list_begin" || line == "command_list_ok_begin":
+			inList, listOK = true, line == "command_list_ok_begin"
+		case line == "noidle":
+			c.noidle()
+		case line == "close":
+			return
+		default:
+			c.runList([]string{line}, false)
+		}
+	}
+}
+
+// runList() runs commands, stopping at the first error.
+func (c *client) runList(cmds []string, listOK bool) {
+	var resp []string
+	for i, cmd := range cmds {
+		atomic.AddInt64(&c.server.commands, 1)
+		args := splitArgs(cmd)
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "idle" && len(cmds) == 1 {
+			c.idle(args[1:])
+			return
+		}
+		if args[0] != "ping" {
+			c.server.mu.Lock()
+			c.server.received = append(c.server.received, cmd)
+			c.server.mu.Unlock()
+		}
+		lines, err := c.run(args[0], args[1:])
+		if err != nil {
+			code := 5
+			if ack, ok := err.(*Ack); ok {
+				code = ack.Code
+			}
+			resp = append(resp, fmt.Sprintf("ACK [%d@%d] {%s} %s", code, i, args[0], err.Error()))
+			c.write(resp...)
+			return
+		}
+		resp = append(resp, lines...)
+		if listOK {
+			resp = append(resp, "list_OK")
+		}
+	}
+	c.write(append(resp, "OK")...)
+}
+
+func (c *client) run(name string, args []string) ([]string, error) {
+	if name == "ping" {
+		return nil, nil
+	}
+	handler := c.server.handler(name)
+	if handler == nil {
+		return nil, &Ack{Code: 5, Message: fmt.Sprintf("unknown command \"%s\"", name)}
+	}
+	return handler(args)
+}
+
+func (c *client) idle(subsystems []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idling = true
+	c.idleFor = make(map[string]bool, len(subsystems))
+	for _, s := range subsystems {
+		c.idleFor[s] = true
+	}
+	c.flushIdle(false)
+}
+
+func (c *client) noidle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.idling {
+		c.flushIdle(true)
+	}
+}
+
+func (c *client) notify(subsystems []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range subsystems {
+		c.pending[s] = true
+	}
+	if c.idling {
+		c.flushIdle(false)
+	}
+}
+
+// flushIdle() ends an idle if there are changes to report, or
+// unconditionally if force is set. It must be called with mu held.
+func (c *client) flushIdle(force bool) {
+	var changed []string
+	for s := range c.pending {
+		if len(c.idleFor) == 0 || c.idleFor[s] {
+			changed = append(changed, "changed: "+s)
+			delete(c.pending, s)
+		}
+	}
+	if len(changed) == 0 && !force {
+		return
+	}
+	c.idling = false
+	c.writeLocked(append(changed, "OK")...)
+}
+
+func (c *client) write(lines ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeLocked(lines...)
+}
+
+func (c *client) writeLocked(lines ...string) {
+	for _, line := range lines {
+		c.out.WriteString(line + "\n")
+	}
+	c.out.Flush()
+}
+
+// splitArgs() splits a command line into its arguments, removing quotes
+// and backslash escapes.
+func splitArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quoted && ch == '\\' && i+1 < len(line):
+			i++
+			arg.WriteByte(line[i])
+		case ch == '"':
+			quoted = !quoted
+			inArg = true
+		case !quoted && (ch == ' ' || ch == '\t'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
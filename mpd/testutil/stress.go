@@ -0,0 +1,247 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// StressConfig configures a stress run.
+type StressConfig struct {
+	Goroutines int // number of concurrent workers, default 8
+	Iterations int // operations per worker, default 100
+
+	// DropEvery, if non-zero, makes the server drop every connection at
+	// this interval while the run is in progress, to exercise error and
+	// reconnect handling.
+	DropEvery time.Duration
+}
+
+func (cfg StressConfig) withDefaults() StressConfig {
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 8
+	}
+	if cfg.Iterations <= 0 {
+		cfg.Iterations = 100
+	}
+	return cfg
+}
+
+// StressReport summarizes a stress run.
+type StressReport struct {
+	Ops    int64 // operations attempted
+	Errors int64 // operations that returned an error
+
+	// Failures holds responses that were wrong, as opposed to errors,
+	// which can be expected when connections are dropped. Any failure
+	// means responses were delivered to the wrong caller or corrupted.
+	Failures []error
+
+	// Resyncs counts the times a watcher reported its subsystems again
+	// after losing its connection. Only StressWatcher() sets it.
+	Resyncs int64
+}
+
+// OK() reports whether the run saw no failures.
+func (r *StressReport) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// Op is a single operation in a stress run. It returns an error if the
+// operation failed, or a *Failure if it succeeded with a wrong result.
+type Op func(worker, iteration int) error
+
+// Failure is returned by an Op whose result was wrong.
+type Failure struct {
+	Worker, Iteration int
+	Message           string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("worker %d iteration %d: %s", f.Worker, f.Iteration, f.Message)
+}
+
+// Stress() runs op from many goroutines at once, dropping the server's
+// connections at the configured interval, and reports the outcome. All
+// bookkeeping is done with atomics and channels, so running it under the
+// race detector checks op itself.
+func Stress(srv *Server, cfg StressConfig, op Op) *StressReport {
+	cfg = cfg.withDefaults()
+	report := new(StressReport)
+	failures := make(chan error)
+	collected := make(chan struct{})
+	go func() {
+		for failure := range failures {
+			report.Failures = append(report.Failures, failure)
+		}
+		close(collected)
+	}()
+
+	stop := make(chan struct{})
+	var dropper sync.WaitGroup
+	if cfg.DropEvery > 0 {
+		dropper.Add(1)
+		go func() {
+			defer dropper.Done()
+			ticker := time.NewTicker(cfg.DropEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					srv.DropConnections()
+				}
+			}
+		}()
+	}
+
+	var workers sync.WaitGroup
+	for w := 0; w < cfg.Goroutines; w++ {
+		workers.Add(1)
+		go func(w int) {
+			defer workers.Done()
+			for i := 0; i < cfg.Iterations; i++ {
+				atomic.AddInt64(&report.Ops, 1)
+				err := op(w, i)
+				if failure, ok := err.(*Failure); ok {
+					failures <- failure
+				} else if err != nil {
+					atomic.AddInt64(&report.Errors, 1)
+				}
+			}
+		}(w)
+	}
+	workers.Wait()
+	close(stop)
+	dropper.Wait()
+	close(failures)
+	<-collected
+	return report
+}
+
+// ConnOp returns an operation that sends a mix of single commands and
+// command lists over conn, each tagged with a token unique to the worker
+// and iteration, and checks that the echoed token comes back to the
+// caller that sent it. It must be used with conn connected to srv.
+func ConnOp(conn *mpd.Conn) Op {
+	return func(w, i int) error {
+		token := strconv.Itoa(w) + "-" + strconv.Itoa(i)
+		var want []string
+		var got []string
+		switch i % 3 {
+		case 0:
+			return conn.Ping()
+		case 1:
+			resp, err := conn.Send("echo " + token)
+			if err != nil {
+				return err
+			}
+			for _, kv := range resp {
+				got = append(got, kv.Key+": "+kv.Value)
+			}
+			want = []string{"echo: " + token}
+		default:
+			resp, err := conn.SendList([]string{"echo " + token + "-a", "ping", "echo " + token + "-b"})
+			if err != nil {
+				return err
+			}
+			for _, kv := range resp {
+				got = append(got, kv.Key+": "+kv.Value)
+			}
+			want = []string{"echo: " + token + "-a", "echo: " + token + "-b"}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return &Failure{w, i, fmt.Sprintf("got %q, want %q", got, want)}
+		}
+		return nil
+	}
+}
+
+// StressConn() hammers a connection to srv from many goroutines with
+// ConnOp().
+func StressConn(conn *mpd.Conn, srv *Server, cfg StressConfig) *StressReport {
+	return Stress(srv, cfg, ConnOp(conn))
+}
+
+// PoolOp returns an operation like ConnOp(), but one that takes a
+// connection from pool for each operation, so that the workers share the
+// pool's connections. The pool must be for srv.
+func PoolOp(pool *mpd.Pool) Op {
+	return func(w, i int) error {
+		return pool.Do(context.Background(), func(conn *mpd.Conn) error {
+			return ConnOp(conn)(w, i)
+		})
+	}
+}
+
+// StressPool() hammers a pool of connections to srv from many goroutines
+// with PoolOp().
+func StressPool(pool *mpd.Pool, srv *Server, cfg StressConfig) *StressReport {
+	return Stress(srv, cfg, PoolOp(pool))
+}
+
+// StressWatcher() starts a watcher on srv for the player and mixer
+// subsystems and notifies it of changes to the player from many
+// goroutines while draining its events, then closes it. It fails if the
+// watcher doesn't shut down promptly or delivers an event for another
+// subsystem. After a dropped connection, the watcher reports both of its
+// subsystems once more to resync, which is expected; the mixer events
+// that result are counted as resyncs in the report. The watcher connects with the given options,
+// such as a quicker WithReconnect() policy.
+func StressWatcher(srv *Server, cfg StressConfig, opts ...mpd.Option) *StressReport {
+	cfg = cfg.withDefaults()
+	subsystems := []mpd.Subsystem{mpd.PlayerSubsystem, mpd.MixerSubsystem}
+	w, err := mpd.NewWatcherWithOptions(srv.Addr(), subsystems, opts...)
+	if err != nil {
+		return &StressReport{Failures: []error{err}}
+	}
+	w.Start()
+
+	var bad []error
+	var resyncs int64
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		events, errs := w.Events, w.Errors
+		for events != nil || errs != nil {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					events = nil
+				} else if ev.Subsystem() == mpd.MixerSubsystem {
+					resyncs++
+				} else if ev.Subsystem() != mpd.PlayerSubsystem {
+					bad = append(bad, fmt.Errorf("unexpected event for subsystem %q", ev.Subsystem()))
+				}
+			case _, ok := <-errs:
+				if !ok {
+					errs = nil
+				}
+			}
+		}
+	}()
+
+	report := Stress(srv, cfg, func(worker, i int) error {
+		srv.Notify("player")
+		if cfg.DropEvery > 0 {
+			// Without a pause, the notifications crowd out the drops.
+			time.Sleep(100 * time.Microsecond)
+		}
+		return nil
+	})
+	w.Close()
+	select {
+	case <-drained:
+		report.Resyncs = resyncs
+		report.Failures = append(report.Failures, bad...)
+	case <-time.After(5 * time.Second):
+		report.Failures = append(report.Failures, fmt.Errorf("watcher did not shut down after Close"))
+	}
+	return report
+}
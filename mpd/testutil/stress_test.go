@@ -0,0 +1,88 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+	"github.com/dradtke/go-mpd/mpd/testutil"
+)
+
+func startServer(t *testing.T) *testutil.Server {
+	t.Helper()
+	srv, err := testutil.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+var fastReconnect = mpd.WithReconnect(mpd.ReconnectPolicy{
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+})
+
+func checkReport(t *testing.T, report *testutil.StressReport, wantErrors bool) {
+	t.Helper()
+	for _, failure := range report.Failures {
+		t.Error(failure)
+	}
+	if !wantErrors && report.Errors > 0 {
+		t.Errorf("%d of %d operations failed", report.Errors, report.Ops)
+	}
+	if report.Errors == report.Ops {
+		t.Errorf("all %d operations failed", report.Ops)
+	}
+}
+
+func TestStressConn(t *testing.T) {
+	srv := startServer(t)
+	conn, err := mpd.ConnectWithOptions(srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkReport(t, testutil.StressConn(conn, srv, testutil.StressConfig{}), false)
+}
+
+func TestStressConnDropped(t *testing.T) {
+	srv := startServer(t)
+	conn, err := mpd.ConnectWithOptions(srv.Addr(), fastReconnect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cfg := testutil.StressConfig{Iterations: 300, DropEvery: 5 * time.Millisecond}
+	checkReport(t, testutil.StressConn(conn, srv, cfg), true)
+}
+
+func TestStressPool(t *testing.T) {
+	srv := startServer(t)
+	pool := mpd.NewPool(srv.Addr(), 4)
+	defer pool.Close()
+	checkReport(t, testutil.StressPool(pool, srv, testutil.StressConfig{Goroutines: 16}), false)
+}
+
+func TestStressPoolDropped(t *testing.T) {
+	srv := startServer(t)
+	pool := mpd.NewPool(srv.Addr(), 4)
+	defer pool.Close()
+	cfg := testutil.StressConfig{Goroutines: 16, Iterations: 200, DropEvery: 5 * time.Millisecond}
+	checkReport(t, testutil.StressPool(pool, srv, cfg), true)
+}
+
+func TestStressWatcher(t *testing.T) {
+	srv := startServer(t)
+	checkReport(t, testutil.StressWatcher(srv, testutil.StressConfig{}), false)
+}
+
+func TestStressWatcherDropped(t *testing.T) {
+	srv := startServer(t)
+	cfg := testutil.StressConfig{Iterations: 500, DropEvery: 5 * time.Millisecond}
+	report := testutil.StressWatcher(srv, cfg, fastReconnect)
+	checkReport(t, report, false)
+	if report.Resyncs == 0 {
+		t.Error("the watcher never saw a dropped connection")
+	}
+}
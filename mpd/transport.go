@@ -0,0 +1,75 @@
+package mpd
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// NewConn() performs the handshake over an already established transport,
+// such as one end of a net.Pipe(), an SSH channel or a TLS connection, and
+// returns a connection using it. Options apply as they do for
+// ConnectWithOptions(), except those that concern dialing; in particular,
+// a connection made this way can't reconnect.
+//
+// The read and write timeouts only take effect if rw has the deadline
+// methods of net.Conn.
+func NewConn(rw io.ReadWriteCloser, opts ...Option) (*Conn, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.reconnect = nil
+
+	socket, ok := rw.(net.Conn)
+	if !ok {
+		socket = rwConn{rw}
+	}
+	ctx := context.Background()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	s := &session{opts: o}
+	if err := s.start(ctx, socket); err != nil {
+		return nil, err
+	}
+	return s.newConn(), nil
+}
+
+// rwConn adapts an io.ReadWriteCloser to net.Conn. Deadlines are passed on
+// if it supports them and ignored otherwise.
+type rwConn struct {
+	io.ReadWriteCloser
+}
+
+type rwAddr struct{}
+
+func (rwAddr) Network() string { return "rw" }
+func (rwAddr) String() string  { return "rw" }
+
+func (c rwConn) LocalAddr() net.Addr  { return rwAddr{} }
+func (c rwConn) RemoteAddr() net.Addr { return rwAddr{} }
+
+func (c rwConn) SetDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(interface{ SetDeadline(time.Time) error }); ok {
+		return d.SetDeadline(t)
+	}
+	return nil
+}
+
+func (c rwConn) SetReadDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return d.SetReadDeadline(t)
+	}
+	return nil
+}
+
+func (c rwConn) SetWriteDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package mpd
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// UpdateEvent is delivered by UpdateProgress() each time the state of a
+// database update changes.
+type UpdateEvent struct {
+	JobID int  // id of the update job that was started
+	Done  bool // true for the final event
+
+	// Stats is only set on the final event, and holds the difference in
+	// the server statistics from before and after the update.
+	Stats StatsDelta
+}
+
+// Changes() returns the number of songs added or removed by the update.
+func (ev UpdateEvent) Changes() int {
+	if ev.Stats.Songs < 0 {
+		return -ev.Stats.Songs
+	}
+	return ev.Stats.Songs
+}
+
+func (ev UpdateEvent) String() string {
+	if !ev.Done {
+		return fmt.Sprintf("updating (job %d)", ev.JobID)
+	}
+	return fmt.Sprintf("done, %d changes", ev.Changes())
+}
+
+// Update() starts a database update of the given path, or of the whole
+// music directory if uri is empty. It returns the id of the update job.
+func (conn *Conn) Update(uri string) (int, error) {
+	cmd := "update"
+	if uri != "" {
+		cmd += " " + quote(uri)
+	}
+	attrs, err := conn.attrs(cmd)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(attrs["updating_db"])
+}
+
+// UpdateProgress() starts a database update like Update(), then blocks
+// until the update has finished. The progress callback, if non-nil, is
+// called with an event once when the job starts, once for every update
+// notification received while it is running, and finally with a summary
+// of how the database changed.
+//
+// The connection is parked in idle while waiting, so it should not be
+// shared with other goroutines for the duration of the call.
+func (conn *Conn) UpdateProgress(uri string, progress func(UpdateEvent)) error {
+	if progress == nil {
+		progress = func(UpdateEvent) {}
+	}
+	before, err := conn.Stats()
+	if err != nil {
+		return err
+	}
+	job, err := conn.Update(uri)
+	if err != nil {
+		return err
+	}
+	progress(UpdateEvent{JobID: job})
+	for notified := false; ; notified = true {
+		status, err := conn.Status()
+		if err != nil {
+			return err
+		}
+		// MPD only reports the job currently running; ours has finished
+		// once the field is gone or another job has taken its place. Job
+		// ids wrap around, so a later job may well have a lower id.
+		if status.UpdatingDB != job {
+			break
+		}
+		if notified {
+			progress(UpdateEvent{JobID: job})
+		}
+		if _, err := conn.Idle(UpdateSubsystem, DatabaseSubsystem); err != nil {
+			return err
+		}
+	}
+	after, err := conn.Stats()
+	if err != nil {
+		return err
+	}
+	progress(UpdateEvent{JobID: job, Done: true, Stats: diffStats(before, after)})
+	return nil
+}
@@ -0,0 +1,76 @@
+package mpd_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+func TestUpdateProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		job    int
+		status []int // updating_db reported by each status, 0 for none
+		events int   // progress events before the final one
+	}{
+		{"finished at once", 7, []int{0}, 1},
+		{"running then finished", 7, []int{7, 7, 0}, 2},
+		{"replaced by a later job", 7, []int{7, 8}, 1},
+		{"replaced after wrapping around", 2147483647, []int{2147483647, 1}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := startServer(t)
+			var mu sync.Mutex
+			statuses, songs := test.status, 10
+			srv.Handle("update", respond("updating_db: "+strconv.Itoa(test.job)))
+			srv.Handle("stats", func(args []string) ([]string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				return []string{"songs: " + strconv.Itoa(songs)}, nil
+			})
+			srv.Handle("status", func(args []string) ([]string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(statuses) == 0 {
+					t.Error("status sent after the update finished")
+					return nil, nil
+				}
+				id := statuses[0]
+				statuses = statuses[1:]
+				if id == 0 {
+					songs = 12
+					return nil, nil
+				}
+				if id != test.job {
+					songs = 12
+				}
+				// Report progress, which ends the following idle.
+				srv.Notify("update")
+				return []string{"updating_db: " + strconv.Itoa(id)}, nil
+			})
+			conn := connect(t, srv)
+
+			var events []mpd.UpdateEvent
+			err := conn.UpdateProgress("", func(ev mpd.UpdateEvent) {
+				events = append(events, ev)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != test.events+1 {
+				t.Fatalf("got %d events, want %d: %v", len(events), test.events+1, events)
+			}
+			for i, ev := range events {
+				if ev.JobID != test.job || ev.Done != (i == len(events)-1) {
+					t.Errorf("event %d = %+v", i, ev)
+				}
+			}
+			if last := events[len(events)-1]; last.Changes() != 2 {
+				t.Errorf("final event reports %d changes, want 2", last.Changes())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package mpd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is an MPD protocol version, as announced by the server when
+// connecting.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion() parses a version such as "0.23.5". Missing trailing
+// components are taken to be zero.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	parts := strings.SplitN(s, ".", 3)
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if i >= len(parts) {
+			break
+		}
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version: %q", s)
+		}
+		*field = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// AtLeast() reports whether the version is major.minor.patch or later,
+// for checking whether the server supports a feature:
+//
+//	if conn.ProtocolVersion().AtLeast(0, 23, 0) {
+//		// getvol is available
+//	}
+func (v Version) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+// ProtocolVersion() returns the server's protocol version, parsed. It is
+// the zero Version if the server announced one that couldn't be parsed.
+func (conn *Conn) ProtocolVersion() Version {
+	v, _ := ParseVersion(conn.serverVersion())
+	return v
+}
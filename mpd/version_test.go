@@ -0,0 +1,47 @@
+package mpd_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dradtke/go-mpd/mpd"
+)
+
+// TestProtocolVersionReconnect checks that the version is picked up again
+// on reconnecting, and can be read safely while that happens.
+func TestProtocolVersionReconnect(t *testing.T) {
+	srv := startServer(t)
+	conn := connect(t, srv, mpd.WithReconnect(mpd.ReconnectPolicy{InitialBackoff: time.Millisecond}))
+	if !conn.ProtocolVersion().AtLeast(0, 23, 5) {
+		t.Fatalf("ProtocolVersion() = %v", conn.ProtocolVersion())
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			conn.ProtocolVersion()
+			conn.Version()
+		}
+	}()
+	srv.SetVersion("0.24.0")
+	for range 5 {
+		srv.DropConnections()
+		if err := conn.Ping(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if v := conn.Version(); v != "0.24.0" {
+		t.Errorf("Version() after reconnecting = %q, want 0.24.0", v)
+	}
+}
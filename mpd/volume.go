@@ -0,0 +1,143 @@
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNoMixer is returned by Volume() when the server has no mixer to
+// control the volume with.
+var ErrNoMixer = errors.New("no mixer")
+
+// Volume() returns the volume, from 0 to 100. It uses getvol on servers
+// that support it and the status on older ones. If there is no mixer, it
+// returns -1 along with ErrNoMixer.
+func (conn *Conn) Volume() (int, error) {
+	if !conn.ProtocolVersion().AtLeast(0, 23, 0) {
+		status, err := conn.Status()
+		if err != nil {
+			return -1, err
+		}
+		if status.Volume < 0 {
+			return -1, ErrNoMixer
+		}
+		return status.Volume, nil
+	}
+	attrs, err := conn.attrs("getvol")
+	if err != nil {
+		return -1, err
+	}
+	vol, err := strconv.Atoi(attrs["volume"])
+	if err != nil || vol < 0 {
+		return -1, ErrNoMixer
+	}
+	return vol, nil
+}
+
+// ChangeVolume() raises the volume by delta, or lowers it if delta is
+// negative, stopping at 0 and 100, as volume keys do. On servers from 0.23
+// on, which clamp the volume themselves, this takes a single command;
+// older ones get the volume and then set it. A delta beyond 100 either
+// way is clamped to it, since the server rejects one out of that range.
+func (conn *Conn) ChangeVolume(delta int) error {
+	delta = min(max(delta, -100), 100)
+	if conn.ProtocolVersion().AtLeast(0, 23, 0) {
+		_, err := conn.exec("volume " + strconv.Itoa(delta))
+		return err
+	}
+	vol, err := conn.Volume()
+	if err != nil {
+		return err
+	}
+	_, err = conn.exec("setvol " + strconv.Itoa(min(max(vol+delta, 0), 100)))
+	return err
+}
+
+// fadeInterval is the shortest time between volume changes during a fade.
+const fadeInterval = 50 * time.Millisecond
+
+// Fade is a volume fade in progress, started by FadeTo().
+type Fade struct {
+	stop chan struct{}
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// FadeTo() gradually changes the volume to target, from 0 to 100, over
+// the given duration, such as for sleep timers or alarm clocks. The fade
+// runs on a goroutine of its own, timed by the Clock set with WithClock(),
+// and can be stopped part way with Stop(). A zero duration sets the volume
+// right away.
+func (conn *Conn) FadeTo(target int, over time.Duration) (*Fade, error) {
+	if target < 0 || target > 100 {
+		return nil, fmt.Errorf("volume level %d is outside valid range of 0-100", target)
+	}
+	if over < 0 {
+		return nil, fmt.Errorf("negative fade duration %s", over)
+	}
+	start, err := conn.Volume()
+	if err != nil {
+		return nil, err
+	}
+	fade := &Fade{stop: make(chan struct{}), done: make(chan struct{})}
+	if over == 0 {
+		if _, err := conn.exec("setvol " + strconv.Itoa(target)); err != nil {
+			return nil, err
+		}
+		close(fade.done)
+		return fade, nil
+	}
+	go fade.run(conn, clockOrSystem(conn.opts.clock), start, target, over)
+	return fade, nil
+}
+
+func (fade *Fade) run(conn *Conn, clock Clock, start, target int, over time.Duration) {
+	defer close(fade.done)
+	steps := target - start
+	if steps < 0 {
+		steps = -steps
+	}
+	if steps == 0 {
+		return
+	}
+	// Change the volume by a point at a time if the fade is slow enough,
+	// and by bigger leaps otherwise.
+	steps = max(min(steps, int(over/fadeInterval)), 1)
+	ticker := clock.NewTicker(over / time.Duration(steps))
+	defer ticker.Stop()
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-fade.stop:
+			return
+		case <-ticker.C():
+		}
+		vol := start + (target-start)*i/steps
+		if _, err := conn.exec("setvol " + strconv.Itoa(vol)); err != nil {
+			fade.err = err
+			return
+		}
+	}
+}
+
+// Stop() stops the fade, leaving the volume where it got to.
+func (fade *Fade) Stop() {
+	fade.once.Do(func() { close(fade.stop) })
+	<-fade.done
+}
+
+// Done() returns a channel that is closed once the fade has finished or
+// been stopped.
+func (fade *Fade) Done() <-chan struct{} {
+	return fade.done
+}
+
+// Err() returns the error that ended the fade early, if any, once it is
+// done.
+func (fade *Fade) Err() error {
+	<-fade.done
+	return fade.err
+}
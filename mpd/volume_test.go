@@ -0,0 +1,58 @@
+package mpd_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestChangeVolume(t *testing.T) {
+	tests := []struct {
+		delta int
+		want  string
+	}{
+		{5, "volume 5"},
+		{-5, "volume -5"},
+		{100, "volume 100"},
+		{250, "volume 100"},
+		{-1000, "volume -100"},
+	}
+	for _, test := range tests {
+		srv := startServer(t)
+		srv.Handle("volume", respond())
+		conn := connect(t, srv)
+		if err := conn.ChangeVolume(test.delta); err != nil {
+			t.Fatalf("ChangeVolume(%d): %v", test.delta, err)
+		}
+		if got := srv.Received(); !reflect.DeepEqual(got, []string{test.want}) {
+			t.Errorf("ChangeVolume(%d) sent %q, want %q", test.delta, got, test.want)
+		}
+	}
+}
+
+func TestChangeVolumeOldServer(t *testing.T) {
+	tests := []struct {
+		vol, delta int
+		want       string
+	}{
+		{50, 5, "setvol 55"},
+		{50, -60, "setvol 0"},
+		{90, 20, "setvol 100"},
+		{50, 1 << 62, "setvol 100"},
+		{50, -1 << 62, "setvol 0"},
+	}
+	for _, test := range tests {
+		srv := startServer(t)
+		srv.SetVersion("0.22.0")
+		srv.Handle("status", respond("volume: "+strconv.Itoa(test.vol), "state: play"))
+		srv.Handle("setvol", respond())
+		conn := connect(t, srv)
+		if err := conn.ChangeVolume(test.delta); err != nil {
+			t.Fatalf("ChangeVolume(%d): %v", test.delta, err)
+		}
+		want := []string{"status", test.want}
+		if got := srv.Received(); !reflect.DeepEqual(got, want) {
+			t.Errorf("ChangeVolume(%d) at %d sent %q, want %q", test.delta, test.vol, got, want)
+		}
+	}
+}
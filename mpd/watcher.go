@@ -0,0 +1,318 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// Event is delivered by a Watcher when a subsystem changes.
+type Event interface {
+	// Subsystem() returns the subsystem that changed.
+	Subsystem() Subsystem
+}
+
+// SubsystemChanged is the event delivered for a subsystem whose changes
+// aren't resolved into a more specific event.
+type SubsystemChanged Subsystem
+
+func (ev SubsystemChanged) Subsystem() Subsystem {
+	return Subsystem(ev)
+}
+
+// allSubsystems lists every subsystem, for reporting changes to all of
+// them at once.
+var allSubsystems = []Subsystem{
+	DatabaseSubsystem, UpdateSubsystem, StoredPlaylistSubsystem,
+	PlaylistSubsystem, PlayerSubsystem, MixerSubsystem, OutputSubsystem,
+	OptionsSubsystem, PartitionSubsystem, StickerSubsystem,
+	SubscriptionSubsystem, MessageSubsystem, NeighborSubsystem,
+	MountSubsystem,
+}
+
+// Watcher waits for changes on a connection of its own and delivers
+// them as events.
+//
+// The connection reconnects by itself if it is lost. Changes made while
+// it was down can't be known, so once it is back, the watcher delivers an
+// event for every subsystem it watches, prompting the application to
+// refresh whatever it shows.
+type Watcher struct {
+	Events chan Event // closed when the watcher stops
+	Errors chan error // closed when the watcher stops
+
+	// ResolveOutputs makes the watcher deliver an OutputsChanged event,
+	// instead of a plain SubsystemChanged, for changes to the outputs.
+	// It must be set before calling Start().
+	ResolveOutputs bool
+
+	// ResolveOptions makes the watcher deliver an OptionsChanged event
+	// for changes to the playback options. It must be set before calling
+	// Start().
+	ResolveOptions bool
+
+	// ResolvePlaylists makes the watcher deliver a PlaylistsChanged
+	// event for changes to the stored playlists. It must be set before
+	// calling Start().
+	ResolvePlaylists bool
+
+	// ResolvePlayer makes the watcher deliver a PlayerChanged event for
+	// changes to the player. It must be set before calling Start().
+	ResolvePlayer bool
+
+	conn      *Conn
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lock         sync.Mutex
+	subsystems   []Subsystem
+	wake         context.CancelFunc // interrupts the current idle
+	reconnecting bool               // true while the connection is down
+	resync       bool               // true if every subsystem must be reported
+
+	outputs   []Output         // last known outputs, if resolving them
+	options   PlaybackOptions  // last known options, if resolving them
+	playlists []StoredPlaylist // last known playlists, if resolving them
+	player    Status           // last known status, if resolving the player
+}
+
+// NewWatcher() connects to the server and prepares a watcher for the
+// given subsystems, or for all of them if none are given. Call Start()
+// to begin receiving events.
+func NewWatcher(addr string, subsystems ...Subsystem) (*Watcher, error) {
+	return NewWatcherWithOptions(addr, subsystems)
+}
+
+// NewWatcherWithOptions() is like NewWatcher(), but connects with the
+// given options. The watcher's connection reconnects as set by
+// WithReconnect() if it is among them, and with the defaults otherwise.
+func NewWatcherWithOptions(addr string, subsystems []Subsystem, opts ...Option) (*Watcher, error) {
+	opts = append([]Option{WithReconnect(ReconnectPolicy{})}, opts...)
+	conn, err := ConnectWithOptions(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Events:     make(chan Event),
+		Errors:     make(chan error),
+		conn:       conn,
+		subsystems: slices.Clone(subsystems),
+		done:       make(chan struct{}),
+	}
+	conn.OnStateChange(w.stateChanged)
+	return w, nil
+}
+
+// Subsystems() returns the subsystems being watched, or nil if all of
+// them are.
+func (w *Watcher) Subsystems() []Subsystem {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return slices.Clone(w.subsystems)
+}
+
+// SetSubsystems() changes the subsystems being watched, or watches all of
+// them if none are given. It takes effect right away, even while the
+// watcher is waiting for changes.
+func (w *Watcher) SetSubsystems(subsystems ...Subsystem) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.subsystems = slices.Clone(subsystems)
+	w.wakeLocked()
+}
+
+// stateChanged() notices the connection coming back after being lost.
+func (w *Watcher) stateChanged(ev ConnEvent) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	switch ev.State {
+	case StateReconnecting:
+		w.reconnecting = true
+	case StateConnected, StateAuthenticated:
+		if w.reconnecting {
+			w.reconnecting = false
+			w.resync = true
+			w.wakeLocked()
+		}
+	}
+}
+
+// wakeLocked() interrupts the current idle, so that the loop picks up
+// changes to the watcher. It must be called with the lock held.
+func (w *Watcher) wakeLocked() {
+	if w.wake != nil {
+		w.wake()
+	}
+}
+
+// Start() starts delivering events.
+func (w *Watcher) Start() {
+	go w.loop()
+}
+
+// Close() stops the watcher and closes its connection.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.lock.Lock()
+		w.wakeLocked()
+		w.lock.Unlock()
+		err = w.conn.Close()
+	})
+	return err
+}
+
+func (w *Watcher) loop() {
+	defer close(w.Events)
+	defer close(w.Errors)
+
+	if w.ResolveOutputs {
+		outputs, err := w.conn.Outputs()
+		if err != nil && !w.sendError(err) {
+			return
+		}
+		w.outputs = outputs
+	}
+	if w.ResolveOptions {
+		options, err := w.conn.PlaybackOptions()
+		if err != nil && !w.sendError(err) {
+			return
+		}
+		w.options = options
+	}
+	if w.ResolvePlaylists {
+		playlists, err := w.conn.ListPlaylists()
+		if err != nil && !w.sendError(err) {
+			return
+		}
+		w.playlists = playlists
+	}
+	w.player = Status{SongID: -1}
+	if w.ResolvePlayer {
+		status, err := w.conn.Status()
+		if err != nil && !w.sendError(err) {
+			return
+		}
+		w.player = status
+	}
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		w.lock.Lock()
+		subsystems := w.subsystems
+		resync := w.resync
+		w.resync = false
+		w.wake = cancel
+		w.lock.Unlock()
+
+		var changed []Subsystem
+		var err error
+		if resync {
+			changed = subsystems
+			if len(changed) == 0 {
+				changed = allSubsystems
+			}
+		} else {
+			changed, err = w.conn.WithContext(ctx).Idle(subsystems...)
+		}
+		woken := ctx.Err() != nil
+		cancel()
+
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		if err != nil {
+			if woken && errors.Is(err, context.Canceled) {
+				continue
+			}
+			if errors.Is(err, ErrClosed) || !w.sendError(err) {
+				return
+			}
+			continue
+		}
+		for _, subsystem := range changed {
+			if !w.sendEvent(w.resolve(subsystem)) {
+				return
+			}
+		}
+	}
+}
+
+// resolve() turns a changed subsystem into an event, fetching whatever
+// is needed to describe the change if resolution is enabled.
+func (w *Watcher) resolve(subsystem Subsystem) Event {
+	switch {
+	case subsystem == OutputSubsystem && w.ResolveOutputs:
+		outputs, err := w.conn.Outputs()
+		if err != nil {
+			w.sendError(err)
+			break
+		}
+		ev := diffOutputs(w.outputs, outputs)
+		w.outputs = outputs
+		return ev
+	case subsystem == OptionsSubsystem && w.ResolveOptions:
+		options, err := w.conn.PlaybackOptions()
+		if err != nil {
+			w.sendError(err)
+			break
+		}
+		ev := diffOptions(w.options, options)
+		w.options = options
+		return ev
+	case subsystem == StoredPlaylistSubsystem && w.ResolvePlaylists:
+		playlists, err := w.conn.ListPlaylists()
+		if err != nil {
+			w.sendError(err)
+			break
+		}
+		ev := diffPlaylists(w.playlists, playlists)
+		w.playlists = playlists
+		return ev
+	case subsystem == PlayerSubsystem && w.ResolvePlayer:
+		status, err := w.conn.Status()
+		if err != nil {
+			w.sendError(err)
+			break
+		}
+		ev := PlayerChanged{
+			State:      status.State,
+			SongID:     status.SongID,
+			PrevState:  w.player.State,
+			PrevSongID: w.player.SongID,
+		}
+		w.player = status
+		return ev
+	}
+	return SubsystemChanged(subsystem)
+}
+
+// sendEvent() delivers an event, returning false if the watcher was
+// closed first.
+func (w *Watcher) sendEvent(ev Event) bool {
+	select {
+	case w.Events <- ev:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// sendError() delivers an error, returning false if the watcher was
+// closed first. Errors caused by closing the watcher aren't delivered.
+func (w *Watcher) sendError(err error) bool {
+	select {
+	case <-w.done:
+		return false
+	default:
+	}
+	select {
+	case w.Errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}